@@ -0,0 +1,284 @@
+// Package cmd contains typed builders of mpv commands.
+//
+// Every constructor returns Command, which can be passed to mpv as named-argument node
+// (Node, for CommandNode) or as list of strings (Args, for Command):
+//
+//	m.CommandNode(cmd.LoadFile(url, cmd.Append, nil).Node())
+//	m.Command(cmd.Seek(10*time.Second, cmd.Absolute|cmd.Exact).Args())
+package cmd
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+// Command is mpv command with its arguments
+type Command struct {
+	name   string
+	names  []string // names of arguments, empty for arguments used only in positional form
+	values []mpv.Node
+	args   []string
+}
+
+func newCommand(name string) Command {
+	return Command{name: name, args: []string{name}}
+}
+
+// with adds argument with name used in named form, value used in node forms and text used in positional form.
+// Slices are copied, so commands can be extended without modifying the original.
+func (c Command) with(name string, value mpv.Node, text string) Command {
+	c.names = append(c.names[:len(c.names):len(c.names)], name)
+	c.values = append(c.values[:len(c.values):len(c.values)], value)
+	c.args = append(c.args[:len(c.args):len(c.args)], text)
+	return c
+}
+
+func (c Command) withString(name, value string) Command {
+	return c.with(name, mpv.Node{Data: value, Format: mpv.FormatString}, value)
+}
+
+func (c Command) withInt(name string, value int64) Command {
+	return c.with(name, mpv.Node{Data: value, Format: mpv.FormatInt64}, strconv.FormatInt(value, 10))
+}
+
+func (c Command) withDouble(name string, value float64) Command {
+	return c.with(name, mpv.Node{Data: value, Format: mpv.FormatDouble}, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// Name returns name of the command
+func (c Command) Name() string {
+	return c.name
+}
+
+// Node returns command as node for CommandNode.
+//
+// It is named-argument map, except for commands which have argument called "name"
+// (e.g. set, add and cycle). mpv uses "name" key for name of the command,
+// so these commands are returned as list of positional arguments.
+func (c Command) Node() *mpv.Node {
+	positional := false
+	for _, name := range c.names {
+		if name == "name" {
+			positional = true
+		}
+	}
+
+	if positional {
+		list := mpv.NodeList{{Data: c.name, Format: mpv.FormatString}}
+		list = append(list, c.values...)
+		return &mpv.Node{Data: list, Format: mpv.FormatNodeArray}
+	}
+
+	named := mpv.NodeMap{"name": {Data: c.name, Format: mpv.FormatString}}
+	for i, name := range c.names {
+		if name != "" {
+			named[name] = c.values[i]
+		}
+	}
+	return &mpv.Node{Data: named, Format: mpv.FormatNodeMap}
+}
+
+// Args returns command as list of positional arguments for Command
+func (c Command) Args() []string {
+	return append([]string(nil), c.args...)
+}
+
+// Run runs the command as named-argument node and returns its result
+func (c Command) Run(p mpv.Player) (*mpv.Node, error) {
+	return p.CommandNode(c.Node())
+}
+
+// LoadFlag decides where file loaded with LoadFile is placed in the playlist
+type LoadFlag string
+
+const (
+	Replace        LoadFlag = "replace"          // stop playback and play the file immediately
+	Append         LoadFlag = "append"           // append the file to the playlist
+	AppendPlay     LoadFlag = "append-play"      // append the file and start playback if nothing is playing
+	InsertNext     LoadFlag = "insert-next"      // insert the file after current entry
+	InsertNextPlay LoadFlag = "insert-next-play" // insert the file after current entry and start playback if nothing is playing
+)
+
+// LoadFile loads url with provided flag. opts are per-file options (e.g. {"start": "10"}), can be nil.
+//
+// Positional form of command with options requires mpv 0.38 or newer (which added index argument),
+// named form works with older versions too.
+func LoadFile(url string, flag LoadFlag, opts map[string]string) Command {
+	c := newCommand("loadfile").withString("url", url).withString("flags", string(flag))
+	if len(opts) == 0 {
+		return c
+	}
+
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	options := make(mpv.NodeMap, len(opts))
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		options[k] = mpv.Node{Data: opts[k], Format: mpv.FormatString}
+		parts[i] = k + "=" + quoteValue(opts[k])
+	}
+
+	// index exists only since mpv 0.38, so it is not used in named form
+	c = c.with("", mpv.Node{Data: int64(-1), Format: mpv.FormatInt64}, "-1")
+	return c.with("options", mpv.Node{Data: options, Format: mpv.FormatNodeMap}, strings.Join(parts, ","))
+}
+
+// quoteValue quotes value of key-value list option if it contains separators,
+// using %length% syntax of mpv
+func quoteValue(value string) string {
+	if !strings.ContainsAny(value, ",=%\"'[] ") {
+		return value
+	}
+	return "%" + strconv.Itoa(len(value)) + "%" + value
+}
+
+// SeekFlag is combination of flags of Seek. Zero value is relative seek to keyframe (mpv default).
+type SeekFlag int
+
+const (
+	Relative SeekFlag = 1 << iota
+	Absolute
+	RelativePercent
+	AbsolutePercent
+	Exact
+	Keyframes
+)
+
+var seekFlagNames = []struct {
+	flag SeekFlag
+	name string
+}{
+	{Relative, "relative"},
+	{Absolute, "absolute"},
+	{RelativePercent, "relative-percent"},
+	{AbsolutePercent, "absolute-percent"},
+	{Exact, "exact"},
+	{Keyframes, "keyframes"},
+}
+
+func (f SeekFlag) String() string {
+	var names []string
+	for _, v := range seekFlagNames {
+		if f&v.flag != 0 {
+			names = append(names, v.name)
+		}
+	}
+	if len(names) == 0 {
+		return "relative"
+	}
+	return strings.Join(names, "+")
+}
+
+// Seek seeks by d (Relative) or to d (Absolute)
+func Seek(d time.Duration, flags SeekFlag) Command {
+	return newCommand("seek").withDouble("target", d.Seconds()).withString("flags", flags.String())
+}
+
+// SeekPercent seeks by percent of the file (used with RelativePercent or AbsolutePercent)
+func SeekPercent(percent float64, flags SeekFlag) Command {
+	return newCommand("seek").withDouble("target", percent).withString("flags", flags.String())
+}
+
+// ScreenshotMode decides what is included in the screenshot
+type ScreenshotMode = mpv.ScreenshotMode
+
+const (
+	ScreenshotSubtitles = mpv.ScreenshotSubtitles
+	ScreenshotVideo     = mpv.ScreenshotVideo
+	ScreenshotWindow    = mpv.ScreenshotWindow
+)
+
+// Screenshot saves screenshot into directory and with template set by screenshot options
+func Screenshot(mode ScreenshotMode) Command {
+	return newCommand("screenshot").withString("flags", string(mode))
+}
+
+// ScreenshotToFile saves screenshot into file, format is chosen by its extension
+func ScreenshotToFile(path string, mode ScreenshotMode) Command {
+	return newCommand("screenshot-to-file").withString("filename", path).withString("flags", string(mode))
+}
+
+// SubFlag decides what happens with subtitle added with SubAdd
+type SubFlag string
+
+const (
+	SubSelect SubFlag = "select" // select the subtitle immediately
+	SubAuto   SubFlag = "auto"   // do not select the subtitle
+	SubCached SubFlag = "cached" // select already added subtitle with the same file name instead of adding it again
+)
+
+// SubAdd loads subtitle file. title and lang are optional.
+func SubAdd(url string, flag SubFlag, title, lang string) Command {
+	c := newCommand("sub-add").withString("url", url).withString("flags", string(flag))
+	if title == "" && lang == "" {
+		return c
+	}
+	c = c.withString("title", title)
+	if lang != "" {
+		c = c.withString("lang", lang)
+	}
+	return c
+}
+
+// SubRemove removes external subtitle track with provided ID
+func SubRemove(id int64) Command {
+	return newCommand("sub-remove").withInt("id", id)
+}
+
+// ShowText shows text on OSD. Property expansion (e.g. "${volume}") is applied to text.
+// Negative duration uses "osd-duration" option.
+func ShowText(text string, duration time.Duration) Command {
+	ms := int64(-1)
+	if duration >= 0 {
+		ms = duration.Milliseconds()
+	}
+	return newCommand("show-text").withString("text", text).withInt("duration", ms)
+}
+
+// Cycle switches property to the next value (e.g. "pause" or "sub")
+func Cycle(property string) Command {
+	return newCommand("cycle").withString("name", property).withString("value", "up")
+}
+
+// CycleDown switches property to the previous value
+func CycleDown(property string) Command {
+	return newCommand("cycle").withString("name", property).withString("value", "down")
+}
+
+// Set sets property from string
+func Set(property, value string) Command {
+	return newCommand("set").withString("name", property).withString("value", value)
+}
+
+// Add adds value to numeric property
+func Add(property string, value float64) Command {
+	return newCommand("add").withString("name", property).withDouble("value", value)
+}
+
+// PlaylistNext starts playback of the next entry in the playlist
+func PlaylistNext() Command {
+	return newCommand("playlist-next")
+}
+
+// PlaylistPrev starts playback of the previous entry in the playlist
+func PlaylistPrev() Command {
+	return newCommand("playlist-prev")
+}
+
+// Stop stops playback and clears the playlist
+func Stop() Command {
+	return newCommand("stop")
+}
+
+// Quit exits the player with provided exit code
+func Quit(code int) Command {
+	return newCommand("quit").withInt("code", int64(code))
+}
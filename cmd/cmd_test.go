@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		args []string
+		node string
+	}{
+		{
+			name: "loadfile",
+			cmd:  LoadFile("a.mkv", Append, nil),
+			args: []string{"loadfile", "a.mkv", "append"},
+			node: `{"flags":"append","name":"loadfile","url":"a.mkv"}`,
+		},
+		{
+			name: "loadfile with options",
+			cmd:  LoadFile("a.mkv", Replace, map[string]string{"start": "10", "title": "a, b"}),
+			args: []string{"loadfile", "a.mkv", "replace", "-1", "start=10,title=%4%a, b"},
+			node: `{"flags":"replace","name":"loadfile","options":{"start":"10","title":"a, b"},"url":"a.mkv"}`,
+		},
+		{
+			name: "seek",
+			cmd:  Seek(10*time.Second, Absolute|Exact),
+			args: []string{"seek", "10", "absolute+exact"},
+			node: `{"flags":"absolute+exact","name":"seek","target":10.0}`,
+		},
+		{
+			name: "seek default flags",
+			cmd:  Seek(-1500*time.Millisecond, 0),
+			args: []string{"seek", "-1.5", "relative"},
+			node: `{"flags":"relative","name":"seek","target":-1.5}`,
+		},
+		{
+			name: "seek percent",
+			cmd:  SeekPercent(50, AbsolutePercent),
+			args: []string{"seek", "50", "absolute-percent"},
+			node: `{"flags":"absolute-percent","name":"seek","target":50.0}`,
+		},
+		{
+			name: "screenshot",
+			cmd:  Screenshot(ScreenshotVideo),
+			args: []string{"screenshot", "video"},
+			node: `{"flags":"video","name":"screenshot"}`,
+		},
+		{
+			name: "screenshot-to-file",
+			cmd:  ScreenshotToFile("a.png", ScreenshotWindow),
+			args: []string{"screenshot-to-file", "a.png", "window"},
+			node: `{"filename":"a.png","flags":"window","name":"screenshot-to-file"}`,
+		},
+		{
+			name: "sub-add",
+			cmd:  SubAdd("a.srt", SubSelect, "", ""),
+			args: []string{"sub-add", "a.srt", "select"},
+			node: `{"flags":"select","name":"sub-add","url":"a.srt"}`,
+		},
+		{
+			name: "sub-add with lang",
+			cmd:  SubAdd("a.srt", SubAuto, "", "en"),
+			args: []string{"sub-add", "a.srt", "auto", "", "en"},
+			node: `{"flags":"auto","lang":"en","name":"sub-add","title":"","url":"a.srt"}`,
+		},
+		{
+			name: "sub-remove",
+			cmd:  SubRemove(3),
+			args: []string{"sub-remove", "3"},
+			node: `{"id":3,"name":"sub-remove"}`,
+		},
+		{
+			name: "show-text",
+			cmd:  ShowText("${volume}", 2*time.Second),
+			args: []string{"show-text", "${volume}", "2000"},
+			node: `{"duration":2000,"name":"show-text","text":"${volume}"}`,
+		},
+		{
+			name: "show-text default duration",
+			cmd:  ShowText("a", -1),
+			args: []string{"show-text", "a", "-1"},
+			node: `{"duration":-1,"name":"show-text","text":"a"}`,
+		},
+		{
+			name: "cycle",
+			cmd:  Cycle("pause"),
+			args: []string{"cycle", "pause", "up"},
+			node: `["cycle","pause","up"]`,
+		},
+		{
+			name: "cycle down",
+			cmd:  CycleDown("sub"),
+			args: []string{"cycle", "sub", "down"},
+			node: `["cycle","sub","down"]`,
+		},
+		{
+			name: "set",
+			cmd:  Set("volume", "50"),
+			args: []string{"set", "volume", "50"},
+			node: `["set","volume","50"]`,
+		},
+		{
+			name: "add",
+			cmd:  Add("volume", -5),
+			args: []string{"add", "volume", "-5"},
+			node: `["add","volume",-5.0]`,
+		},
+		{
+			name: "playlist-next",
+			cmd:  PlaylistNext(),
+			args: []string{"playlist-next"},
+			node: `{"name":"playlist-next"}`,
+		},
+		{
+			name: "playlist-prev",
+			cmd:  PlaylistPrev(),
+			args: []string{"playlist-prev"},
+			node: `{"name":"playlist-prev"}`,
+		},
+		{
+			name: "stop",
+			cmd:  Stop(),
+			args: []string{"stop"},
+			node: `{"name":"stop"}`,
+		},
+		{
+			name: "quit",
+			cmd:  Quit(4),
+			args: []string{"quit", "4"},
+			node: `{"code":4,"name":"quit"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if args := tt.cmd.Args(); !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("Args() = %q, want %q", args, tt.args)
+			}
+
+			node, err := json.Marshal(tt.cmd.Node())
+			if err != nil {
+				t.Fatalf("marshal Node(): %v", err)
+			}
+			if string(node) != tt.node {
+				t.Errorf("Node() = %s, want %s", node, tt.node)
+			}
+		})
+	}
+}
+
+func TestCommandIsNotShared(t *testing.T) {
+	base := newCommand("loadfile").withString("url", "a.mkv")
+	a := base.withString("flags", "append")
+	b := base.withString("flags", "replace")
+
+	if args := a.Args(); args[2] != "append" {
+		t.Errorf("first command was modified: %q", args)
+	}
+	if args := b.Args(); args[2] != "replace" {
+		t.Errorf("second command was modified: %q", args)
+	}
+	if args := base.Args(); len(args) != 2 {
+		t.Errorf("base command was modified: %q", args)
+	}
+}
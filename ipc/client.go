@@ -0,0 +1,463 @@
+// Package ipc controls external mpv process through its JSON IPC
+// (mpv started with --input-ipc-server=/path/to/socket).
+//
+// Client uses the same types as package mpv (Node, Event, EProperty, ...).
+// The package does not need libmpv, so it can be built with CGO_ENABLED=0
+// or with "nolibmpv" build tag.
+package ipc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+// ErrClosed is returned for requests which cannot complete because connection was closed
+var ErrClosed = errors.New("ipc: connection closed")
+
+var (
+	_ mpv.Player     = (*Client)(nil)
+	_ mpv.Subscriber = (*Client)(nil)
+)
+
+// Client is connection to mpv JSON IPC server
+type Client struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	lastID   int64
+	pending  map[int64]*request
+	observed map[uint64]mpv.Format
+	closed   bool
+
+	subscribers *mpv.PropertySubscribers
+
+	reading  bool
+	overflow bool
+
+	events chan *mpv.Event
+	done   chan struct{}
+}
+
+// request waits for reply with the same request_id.
+// Synchronous requests receive it through reply, asynchronous are turned into events.
+type request struct {
+	reply chan response
+
+	async  bool
+	id     uint64
+	event  mpv.EventID
+	name   string
+	format mpv.Format
+}
+
+type response struct {
+	code mpv.Error
+	data mpv.Node
+	err  error
+}
+
+type message struct {
+	Event     string          `json:"event"`
+	Error     string          `json:"error"`
+	Data      json.RawMessage `json:"data"`
+	RequestID *int64          `json:"request_id"`
+}
+
+// Dial connects to mpv IPC server listening on unix socket
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient creates client using already established connection
+// (for example named pipe on Windows).
+func NewClient(conn net.Conn) *Client {
+	c := &Client{
+		conn:     conn,
+		pending:  map[int64]*request{},
+		observed: map[uint64]mpv.Format{},
+		events:   make(chan *mpv.Event, 1024),
+		done:     make(chan struct{}),
+
+		subscribers: mpv.NewPropertySubscribers(firstSubscriptionID),
+	}
+	go c.read()
+	return c
+}
+
+// Close closes the connection. mpv process is not affected.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Done returns channel which is closed after connection is closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) read() {
+	decoder := json.NewDecoder(bufio.NewReader(c.conn))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			c.shutdown()
+			return
+		}
+
+		var msg message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+
+		if msg.Event != "" {
+			if ev := decodeEvent(msg.Event, raw, c.observedFormat); ev != nil {
+				c.pushEvent(ev)
+			}
+			continue
+		}
+		if msg.RequestID != nil {
+			c.handleReply(*msg.RequestID, msg)
+		}
+	}
+}
+
+// shutdown fails all pending requests and sends EventShutdown
+func (c *Client) shutdown() {
+	c.conn.Close()
+
+	c.mu.Lock()
+	c.closed = true
+	pending := c.pending
+	c.pending = map[int64]*request{}
+	c.mu.Unlock()
+
+	for _, req := range pending {
+		c.resolve(req, response{code: mpv.ErrGeneric, err: ErrClosed})
+	}
+
+	c.pushEvent(&mpv.Event{EventID: mpv.EventShutdown})
+	close(c.done)
+}
+
+func (c *Client) handleReply(requestID int64, msg message) {
+	c.mu.Lock()
+	req, ok := c.pending[requestID]
+	delete(c.pending, requestID)
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	resp := response{code: parseError(msg.Error), data: mpv.Node{Format: mpv.FormatNone}}
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, &resp.data); err != nil {
+			resp.code = mpv.ErrGeneric
+			resp.err = err
+		}
+	}
+	c.resolve(req, resp)
+}
+
+func (c *Client) resolve(req *request, resp response) {
+	if !req.async {
+		req.reply <- resp
+		return
+	}
+
+	ev := &mpv.Event{ID: req.id, Error: resp.code, EventID: req.event}
+	switch req.event {
+	case mpv.EventCommandReply:
+		node := resp.data
+		ev.Data = mpv.ECommandReply(&node)
+	case mpv.EventGetPropertyReply:
+		value, format := convertNode(resp.data, req.format)
+		ev.Data = mpv.EProperty{Name: req.name, Format: format, Property: value}
+	}
+	c.pushEvent(ev)
+}
+
+// send writes command to mpv. If req is not nil, it is registered to receive the reply.
+func (c *Client) send(command interface{}, req *request) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.lastID++
+	id := c.lastID
+	c.pending[id] = req
+	c.mu.Unlock()
+
+	data, err := json.Marshal(struct {
+		Command   interface{} `json:"command"`
+		RequestID int64       `json:"request_id"`
+		Async     bool        `json:"async,omitempty"`
+	}{command, id, req.async})
+	if err == nil {
+		data = append(data, '\n')
+
+		c.writeMu.Lock()
+		_, err = c.conn.Write(data)
+		c.writeMu.Unlock()
+	}
+
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// call sends command and waits for its reply
+func (c *Client) call(op, name string, command interface{}) (*mpv.Node, error) {
+	req := &request{reply: make(chan response, 1)}
+	if err := c.send(command, req); err != nil {
+		return nil, &mpv.OpError{Op: op, Name: name, Code: mpv.ErrGeneric, Err: err}
+	}
+
+	resp := <-req.reply
+	if resp.code < 0 {
+		return nil, &mpv.OpError{Op: op, Name: name, Code: resp.code, Err: resp.err}
+	}
+	return &resp.data, nil
+}
+
+// callAsync sends command, reply is delivered as event with provided id
+func (c *Client) callAsync(op, name string, command interface{}, req *request) error {
+	req.async = true
+	if err := c.send(command, req); err != nil {
+		return &mpv.OpError{Op: op, Name: name, Code: mpv.ErrGeneric, Err: err}
+	}
+	return nil
+}
+
+// parseError converts error string sent by mpv into error code
+func parseError(msg string) mpv.Error {
+	for code := mpv.ErrSuccess; code >= mpv.ErrGeneric; code-- {
+		if code.Error() == msg {
+			return code
+		}
+	}
+	return mpv.ErrGeneric
+}
+
+// Command runs command with provided arguments
+func (c *Client) Command(args []string) error {
+	_, err := c.call("command", mpv.CommandName(args), args)
+	return err
+}
+
+// CommandReturn is like Command, but returns result of the command
+func (c *Client) CommandReturn(args []string) (*mpv.Node, error) {
+	return c.call("command", mpv.CommandName(args), args)
+}
+
+// CommandNode runs command passed as Node (array of arguments or map with named arguments)
+func (c *Client) CommandNode(args *mpv.Node) (*mpv.Node, error) {
+	return c.call("command_node", mpv.CommandNodeName(args), args)
+}
+
+// CommandAsync runs command asynchronously, result is delivered as EventCommandReply with provided id
+func (c *Client) CommandAsync(args []string, id uint64) error {
+	return c.callAsync("command_async", mpv.CommandName(args), args, &request{id: id, event: mpv.EventCommandReply})
+}
+
+// CommandAsyncNode is like CommandAsync, but takes arguments as Node
+func (c *Client) CommandAsyncNode(args *mpv.Node, id uint64) error {
+	return c.callAsync("command_node_async", mpv.CommandNodeName(args), args, &request{id: id, event: mpv.EventCommandReply})
+}
+
+// SetProperty sets value of the property. See mpv.NewNode for supported types of value.
+func (c *Client) SetProperty(name string, value interface{}, format mpv.Format) error {
+	node, err := propertyNode(value, format)
+	if err != nil {
+		return &mpv.OpError{Op: "set_property", Name: name, Code: mpv.ErrPropertyFormat, Err: err}
+	}
+	_, err = c.call("set_property", name, []interface{}{"set_property", name, node})
+	return err
+}
+
+// propertyNode converts value into node sent to mpv, checking that it matches format
+// the same way as mpv.Mpv.SetProperty does
+func propertyNode(value interface{}, format mpv.Format) (*mpv.Node, error) {
+	node, err := mpv.NewNode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case mpv.FormatNode:
+		return node, nil
+	case mpv.FormatNodeArray, mpv.FormatNodeMap, mpv.FormatByteArray:
+		return nil, fmt.Errorf("format %d can be used only inside FormatNode", format)
+	case mpv.FormatDouble:
+		if v, ok := node.Data.(int64); ok {
+			return &mpv.Node{Data: float64(v), Format: format}, nil
+		}
+	case mpv.FormatOsdString:
+		format = mpv.FormatString
+	case mpv.FormatString, mpv.FormatFlag, mpv.FormatInt64:
+	default:
+		return nil, fmt.Errorf("unsupported format %d", format)
+	}
+
+	if node.Format != format {
+		return nil, fmt.Errorf("value of type %T cannot be used with format %d", value, format)
+	}
+	return node, nil
+}
+
+// SetPropertyString sets value of the property from string
+func (c *Client) SetPropertyString(name, value string) error {
+	_, err := c.call("set_property_string", name, []string{"set_property_string", name, value})
+	return err
+}
+
+// SetPropertyAsync sets value of the property asynchronously,
+// result is delivered as EventSetPropertyReply with provided id.
+func (c *Client) SetPropertyAsync(name string, value interface{}, id uint64, format mpv.Format) error {
+	node, err := propertyNode(value, format)
+	if err != nil {
+		return &mpv.OpError{Op: "set_property_async", Name: name, Code: mpv.ErrPropertyFormat, Err: err}
+	}
+	command := []interface{}{"set_property", name, node}
+	return c.callAsync("set_property_async", name, command, &request{id: id, event: mpv.EventSetPropertyReply})
+}
+
+// GetProperty returns value of the property converted to provided format
+// (the same Go types as returned by mpv.Mpv.GetProperty).
+func (c *Client) GetProperty(name string, format mpv.Format) (interface{}, error) {
+	result, err := c.call("get_property", name, []string{getPropertyCommand(format), name})
+	if err != nil {
+		return nil, err
+	}
+	value, _ := convertNode(*result, format)
+	return value, nil
+}
+
+// GetPropertyString returns value of the property formatted as string
+func (c *Client) GetPropertyString(name string) (string, error) {
+	result, err := c.call("get_property", name, []string{"get_property_string", name})
+	if err != nil {
+		return "", err
+	}
+	value, _ := result.Data.(string)
+	return value, nil
+}
+
+// GetPropertyAsync gets value of the property asynchronously,
+// result is delivered as EventGetPropertyReply with provided id.
+func (c *Client) GetPropertyAsync(name string, id uint64, format mpv.Format) error {
+	command := []string{getPropertyCommand(format), name}
+	req := &request{id: id, event: mpv.EventGetPropertyReply, name: name, format: format}
+	return c.callAsync("get_property_async", name, command, req)
+}
+
+func getPropertyCommand(format mpv.Format) string {
+	if format == mpv.FormatString || format == mpv.FormatOsdString {
+		return "get_property_string"
+	}
+	return "get_property"
+}
+
+// ObserveProperty starts observing the property, changes are delivered as EventPropertyChange with provided id
+func (c *Client) ObserveProperty(name string, id uint64, format mpv.Format) error {
+	command := "observe_property"
+	if format == mpv.FormatString || format == mpv.FormatOsdString {
+		command = "observe_property_string"
+	}
+
+	c.mu.Lock()
+	c.observed[id] = format
+	c.mu.Unlock()
+
+	_, err := c.call("observe_property", name, []interface{}{command, id, name})
+	return err
+}
+
+// UnObserveProperty stops all observers with provided id.
+// mpv does not report number of removed observers over IPC, so it is 1 if id was observed by this client, otherwise 0.
+func (c *Client) UnObserveProperty(id uint64) (int, error) {
+	c.mu.Lock()
+	_, ok := c.observed[id]
+	delete(c.observed, id)
+	c.mu.Unlock()
+
+	if _, err := c.call("unobserve_property", "", []interface{}{"unobserve_property", id}); err != nil {
+		return 0, err
+	}
+	if ok {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// firstSubscriptionID is the first observer ID used by SubscribeProperty.
+// mpv reads IDs sent over IPC as int64, so IDs above 1<<63 used by package mpv cannot be used.
+const firstSubscriptionID = 1 << 62
+
+// SubscribeProperty calls fn every time the property changes, starting with its current value.
+// Every subscription uses its own observer with automatically allocated ID, its events are also
+// delivered by EventWait and Events. fn is called from goroutine which reads events, so it should not block.
+func (c *Client) SubscribeProperty(name string, format mpv.Format, fn func(mpv.EProperty)) (io.Closer, error) {
+	return c.subscribers.Subscribe(c, name, format, fn)
+}
+
+// SetPanicHandler sets function called when subscriber panics, like mpv.Mpv.SetPanicHandler.
+// Panic is recovered and events are still delivered, even if there is no panic handler. nil fn removes the handler.
+func (c *Client) SetPanicHandler(fn func(ev *mpv.Event, recovered interface{})) {
+	c.subscribers.SetPanicHandler(fn)
+}
+
+func (c *Client) observedFormat(id uint64) mpv.Format {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if format, ok := c.observed[id]; ok {
+		return format
+	}
+	return mpv.FormatNode
+}
+
+// RequestLogMessages enables EventLogMessage for messages with level at least as provided
+func (c *Client) RequestLogMessages(level mpv.LogLevel) error {
+	_, err := c.call("request_log_messages", level.String(), []string{"request_log_messages", level.String()})
+	return err
+}
+
+// RequestEvent enables or disables event
+func (c *Client) RequestEvent(event mpv.EventID, status bool) error {
+	command := "disable_event"
+	if status {
+		command = "enable_event"
+	}
+	name := eventName(event)
+	_, err := c.call("request_event", name, []string{command, name})
+	return err
+}
+
+// HookAdd adds hook, which is delivered as EventHook with provided id
+func (c *Client) HookAdd(name string, priority int, id uint64) error {
+	_, err := c.call("hook_add", name, []interface{}{"hook-add", name, id, priority})
+	return err
+}
+
+// HookContinue continues hook with ID received in EHook
+func (c *Client) HookContinue(id uint64) error {
+	_, err := c.call("hook_continue", "", []interface{}{"hook-ack", id})
+	return err
+}
@@ -0,0 +1,322 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+// serverRequest is command received by fake mpv server
+type serverRequest struct {
+	Command   []interface{} `json:"command"`
+	RequestID int64         `json:"request_id"`
+	Async     bool          `json:"async"`
+}
+
+// server is the other side of net.Pipe, which plays mpv IPC server
+type server struct {
+	t       *testing.T
+	conn    net.Conn
+	decoder *json.Decoder
+}
+
+func newTestClient(t *testing.T) (*Client, *server) {
+	clientConn, serverConn := net.Pipe()
+	c := NewClient(clientConn)
+	t.Cleanup(func() {
+		c.Close()
+		serverConn.Close()
+	})
+	// numbers are kept as json.Number, so large observer IDs are sent back unchanged
+	decoder := json.NewDecoder(serverConn)
+	decoder.UseNumber()
+	return c, &server{t: t, conn: serverConn, decoder: decoder}
+}
+
+func (s *server) read() serverRequest {
+	var req serverRequest
+	if err := s.decoder.Decode(&req); err != nil {
+		s.t.Errorf("read request: %v", err)
+	}
+	return req
+}
+
+func (s *server) write(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		s.t.Errorf("marshal message: %v", err)
+		return
+	}
+	if _, err := s.conn.Write(append(data, '\n')); err != nil {
+		s.t.Errorf("write message: %v", err)
+	}
+}
+
+func (s *server) reply(req serverRequest, data interface{}) {
+	s.write(map[string]interface{}{"request_id": req.RequestID, "error": "success", "data": data})
+}
+
+func TestRequestCorrelation(t *testing.T) {
+	c, s := newTestClient(t)
+
+	values := map[string]interface{}{"volume": 50.5, "pause": true, "path": "a.mkv"}
+	formats := map[string]mpv.Format{"volume": mpv.FormatDouble, "pause": mpv.FormatFlag, "path": mpv.FormatString}
+
+	var wg sync.WaitGroup
+	results := make(map[string]interface{})
+	var mu sync.Mutex
+	for name, format := range formats {
+		wg.Add(1)
+		go func(name string, format mpv.Format) {
+			defer wg.Done()
+			value, err := c.GetProperty(name, format)
+			if err != nil {
+				t.Errorf("GetProperty(%s): %v", name, err)
+				return
+			}
+			mu.Lock()
+			results[name] = value
+			mu.Unlock()
+		}(name, format)
+	}
+
+	requests := make([]serverRequest, len(formats))
+	for i := range requests {
+		requests[i] = s.read()
+	}
+	// reply in reverse order, so replies do not match order of requests
+	for i := len(requests) - 1; i >= 0; i-- {
+		name, _ := requests[i].Command[1].(string)
+		s.reply(requests[i], values[name])
+	}
+	wg.Wait()
+
+	for name, want := range values {
+		if got := results[name]; got != want {
+			t.Errorf("%s = %#v, want %#v", name, got, want)
+		}
+	}
+}
+
+func TestRequestIDs(t *testing.T) {
+	c, s := newTestClient(t)
+
+	go func() {
+		_ = c.Command([]string{"stop"})
+		_ = c.Command([]string{"quit"})
+	}()
+
+	first := s.read()
+	s.reply(first, nil)
+	second := s.read()
+	s.reply(second, nil)
+
+	if first.RequestID == second.RequestID {
+		t.Errorf("requests have the same request_id %d", first.RequestID)
+	}
+	if want := []interface{}{"stop"}; len(first.Command) != 1 || first.Command[0] != want[0] {
+		t.Errorf("first command = %v, want %v", first.Command, want)
+	}
+}
+
+func TestErrorReply(t *testing.T) {
+	c, s := newTestClient(t)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetProperty("chapter", mpv.FormatInt64)
+		done <- err
+	}()
+
+	req := s.read()
+	s.write(map[string]interface{}{"request_id": req.RequestID, "error": "property unavailable"})
+
+	err := <-done
+	if !errors.Is(err, mpv.ErrPropertyUnavailable) {
+		t.Fatalf("got %v, want ErrPropertyUnavailable", err)
+	}
+	var opErr *mpv.OpError
+	if !errors.As(err, &opErr) || opErr.Name != "chapter" {
+		t.Errorf("got %#v, want OpError of chapter", err)
+	}
+}
+
+func TestUnknownReplyIsIgnored(t *testing.T) {
+	c, s := newTestClient(t)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Command([]string{"stop"})
+	}()
+
+	req := s.read()
+	s.write(map[string]interface{}{"request_id": req.RequestID + 100, "error": "error running command"})
+	s.reply(req, nil)
+
+	if err := <-done; err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestAsyncReply(t *testing.T) {
+	c, s := newTestClient(t)
+
+	go func() {
+		req := s.read()
+		if !req.Async {
+			t.Error("async flag is not set")
+		}
+		s.reply(req, map[string]interface{}{"playlist_entry_id": 3})
+	}()
+
+	if err := c.CommandAsync([]string{"loadfile", "a.mkv"}, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := c.EventWait(1)
+	if ev.EventID != mpv.EventCommandReply || ev.ID != 42 || ev.Error != mpv.ErrSuccess {
+		t.Fatalf("got %#v, want command reply with id 42", ev)
+	}
+	result := (*mpv.Node)(ev.Data.(mpv.ECommandReply))
+	values, _ := result.Data.(mpv.NodeMap)
+	if id := values["playlist_entry_id"].Data; id != int64(3) {
+		t.Errorf("playlist_entry_id = %#v, want 3", id)
+	}
+}
+
+func TestCloseFailsPending(t *testing.T) {
+	c, s := newTestClient(t)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Command([]string{"stop"})
+	}()
+
+	s.read()
+	s.conn.Close()
+
+	if err := <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+	if ev := c.EventWait(1); ev.EventID != mpv.EventShutdown {
+		t.Errorf("got event %d, want EventShutdown", ev.EventID)
+	}
+	if err := c.Command([]string{"stop"}); !errors.Is(err, ErrClosed) {
+		t.Errorf("command after close: got %v, want ErrClosed", err)
+	}
+}
+
+func TestSubscribeProperty(t *testing.T) {
+	c, s := newTestClient(t)
+
+	go func() {
+		req := s.read()
+		s.reply(req, nil)
+
+		id := req.Command[1]
+		s.write(map[string]interface{}{"event": "property-change", "id": id, "name": "volume", "data": 30})
+	}()
+
+	changes := make(chan mpv.EProperty, 1)
+	sub, err := c.SubscribeProperty("volume", mpv.FormatDouble, func(e mpv.EProperty) {
+		changes <- e
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev := c.EventWait(1)
+	if ev.EventID != mpv.EventPropertyChange {
+		t.Fatalf("got event %d, want EventPropertyChange", ev.EventID)
+	}
+	select {
+	case e := <-changes:
+		if e.Name != "volume" || e.Property != 30.0 {
+			t.Errorf("got %#v, want volume 30.0", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not called")
+	}
+
+	go func() {
+		req := s.read()
+		if req.Command[0] != "unobserve_property" {
+			t.Errorf("got %v, want unobserve_property", req.Command)
+		}
+		s.reply(req, nil)
+	}()
+	if err := sub.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetPropertyFormat(t *testing.T) {
+	c, s := newTestClient(t)
+
+	tests := []struct {
+		value  interface{}
+		format mpv.Format
+	}{
+		{"50", mpv.FormatDouble},
+		{1.5, mpv.FormatInt64},
+		{true, mpv.FormatString},
+		{mpv.NodeList{}, mpv.FormatNodeArray},
+	}
+	for _, tt := range tests {
+		if err := c.SetProperty("volume", tt.value, tt.format); !errors.Is(err, mpv.ErrPropertyFormat) {
+			t.Errorf("SetProperty(%#v, %d) = %v, want ErrPropertyFormat", tt.value, tt.format, err)
+		}
+		if err := c.SetPropertyAsync("volume", tt.value, 1, tt.format); !errors.Is(err, mpv.ErrPropertyFormat) {
+			t.Errorf("SetPropertyAsync(%#v, %d) = %v, want ErrPropertyFormat", tt.value, tt.format, err)
+		}
+	}
+
+	// int64 is sent as double, like mpv.Mpv converts it
+	go func() {
+		req := s.read()
+		if value, _ := req.Command[2].(json.Number); value != "50" && value != "50.0" {
+			t.Errorf("got %v, want 50", req.Command)
+		}
+		s.reply(req, nil)
+	}()
+	if err := c.SetProperty("volume", 50, mpv.FormatDouble); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownWithFullQueue(t *testing.T) {
+	c, s := newTestClient(t)
+
+	for i := 0; i < cap(c.events)+10; i++ {
+		s.write(map[string]interface{}{"event": "seek"})
+	}
+	s.conn.Close()
+
+	events, err := c.Events(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last *mpv.Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				if last == nil || last.EventID != mpv.EventShutdown {
+					t.Fatalf("last event = %#v, want EventShutdown", last)
+				}
+				return
+			}
+			last = ev
+		case <-timeout:
+			t.Fatal("events were not closed")
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package ipc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+// ErrEventsRunning is returned when events of the client are already consumed by other reader
+var ErrEventsRunning = errors.New("events of this client are already consumed by other reader")
+
+// names of events used by mpv IPC
+var eventNames = map[string]mpv.EventID{
+	"shutdown":           mpv.EventShutdown,
+	"log-message":        mpv.EventLogMessage,
+	"get-property-reply": mpv.EventGetPropertyReply,
+	"set-property-reply": mpv.EventSetPropertyReply,
+	"command-reply":      mpv.EventCommandReply,
+	"start-file":         mpv.EventStartFile,
+	"end-file":           mpv.EventEndFile,
+	"file-loaded":        mpv.EventFileLoaded,
+	"idle":               mpv.EventIdle,
+	"pause":              mpv.EventPause,
+	"unpause":            mpv.EventUnpause,
+	"tick":               mpv.EventTick,
+	"client-message":     mpv.EventClientMessage,
+	"video-reconfig":     mpv.EventVideoReconfig,
+	"audio-reconfig":     mpv.EventAudioReconfig,
+	"seek":               mpv.EventSeek,
+	"playback-restart":   mpv.EventPlaybackRestart,
+	"property-change":    mpv.EventPropertyChange,
+	"queue-overflow":     mpv.EventQueueOverflow,
+	"hook":               mpv.EventHook,
+}
+
+var endFileReasons = map[string]mpv.EndFileReason{
+	"eof":      mpv.EndFileReasonEof,
+	"stop":     mpv.EndFileReasonStop,
+	"quit":     mpv.EndFileReasonQuit,
+	"error":    mpv.EndFileReasonError,
+	"redirect": mpv.EndFileReasonRedirect,
+}
+
+var logLevels = map[string]mpv.LogLevel{
+	"no":    mpv.LogLevelNone,
+	"fatal": mpv.LogLevelFatal,
+	"error": mpv.LogLevelError,
+	"warn":  mpv.LogLevelWarn,
+	"info":  mpv.LogLevelInfo,
+	"v":     mpv.LogLevelV,
+	"debug": mpv.LogLevelDebug,
+	"trace": mpv.LogLevelTrace,
+}
+
+func eventName(event mpv.EventID) string {
+	for name, id := range eventNames {
+		if id == event {
+			return name
+		}
+	}
+	return ""
+}
+
+// fields of event sent by mpv
+type eventFields map[string]mpv.Node
+
+func (f eventFields) String(key string) string {
+	value, _ := f[key].Data.(string)
+	return value
+}
+
+func (f eventFields) Int64(key string) int64 {
+	switch value := f[key].Data.(type) {
+	case int64:
+		return value
+	case float64:
+		return int64(value)
+	}
+	return 0
+}
+
+func (f eventFields) Error(key string) mpv.Error {
+	if _, ok := f[key]; !ok {
+		return mpv.ErrSuccess
+	}
+	return parseError(f.String(key))
+}
+
+// decodeEvent converts event sent by mpv into mpv.Event.
+// Unknown events are ignored and nil is returned.
+func decodeEvent(name string, raw json.RawMessage, observedFormat func(id uint64) mpv.Format) *mpv.Event {
+	id, ok := eventNames[name]
+	if !ok {
+		return nil
+	}
+
+	var fields eventFields
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	ev := &mpv.Event{
+		ID:      uint64(fields.Int64("id")),
+		Error:   fields.Error("error"),
+		EventID: id,
+	}
+
+	switch id {
+	case mpv.EventLogMessage:
+		ev.Data = mpv.ELogMessage{
+			Prefix: fields.String("prefix"),
+			Text:   fields.String("text"),
+			Level:  logLevels[fields.String("level")],
+		}
+	case mpv.EventClientMessage:
+		var args mpv.EClientMessage
+		list, _ := fields["args"].Data.(mpv.NodeList)
+		for _, arg := range list {
+			value, _ := arg.Data.(string)
+			args = append(args, value)
+		}
+		ev.Data = args
+	case mpv.EventStartFile:
+		ev.Data = mpv.EStartFile(fields.Int64("playlist_entry_id"))
+	case mpv.EventEndFile:
+		reason, ok := endFileReasons[fields.String("reason")]
+		if !ok {
+			reason = -1
+		}
+		ev.Data = mpv.EEndFile{
+			PlaylistEntryID:       fields.Int64("playlist_entry_id"),
+			PlaylistInsertID:      fields.Int64("playlist_insert_id"),
+			PlaylistInsertEntries: int(fields.Int64("playlist_insert_num_entries")),
+			Error:                 fields.Error("file_error"),
+			Reason:                reason,
+		}
+	case mpv.EventPropertyChange:
+		value, format := mpv.Node{Format: mpv.FormatNone}, mpv.FormatNone
+		if data, ok := fields["data"]; ok {
+			value, format = data, observedFormat(ev.ID)
+		}
+		property, format := convertNode(value, format)
+		ev.Data = mpv.EProperty{Name: fields.String("name"), Format: format, Property: property}
+	case mpv.EventHook:
+		ev.Data = mpv.EHook{Name: fields.String("name"), ID: uint64(fields.Int64("hook_id"))}
+	}
+	return ev
+}
+
+// convertNode converts value sent by mpv into Go type used by package mpv for provided format.
+// If value does not match the format, it is returned as *mpv.Node with FormatNode.
+func convertNode(node mpv.Node, format mpv.Format) (interface{}, mpv.Format) {
+	if node.Format == mpv.FormatNone || format == mpv.FormatNone {
+		return nil, mpv.FormatNone
+	}
+
+	switch format {
+	case mpv.FormatString, mpv.FormatOsdString:
+		if value, ok := node.Data.(string); ok {
+			return value, format
+		}
+	case mpv.FormatFlag:
+		if value, ok := node.Data.(bool); ok {
+			return value, format
+		}
+	case mpv.FormatInt64:
+		switch value := node.Data.(type) {
+		case int64:
+			return value, format
+		case float64:
+			return int64(value), format
+		}
+	case mpv.FormatDouble:
+		switch value := node.Data.(type) {
+		case int64:
+			return float64(value), format
+		case float64:
+			return value, format
+		}
+	}
+	return &node, mpv.FormatNode
+}
+
+// pushEvent queues event for EventWait or Events.
+// Reader of the connection must never block, so when queue is full the event is dropped
+// and EventQueueOverflow is queued as soon as there is space, like mpv does.
+// Dropped EventShutdown is not lost, readers return it after queued events once c.done is closed.
+func (c *Client) pushEvent(ev *mpv.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.overflow {
+		select {
+		case c.events <- &mpv.Event{EventID: mpv.EventQueueOverflow}:
+			c.overflow = false
+		default:
+		}
+	}
+	if c.overflow {
+		return
+	}
+
+	select {
+	case c.events <- ev:
+	default:
+		c.overflow = true
+	}
+}
+
+// EventWait waits for the next event, like mpv.Mpv.EventWait.
+//
+// timeout is in seconds, negative value waits forever and zero does not wait at all.
+// EventNone is returned on timeout and EventShutdown after connection is closed.
+func (c *Client) EventWait(timeout float64) *mpv.Event {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	ev := c.wait(expired)
+	c.subscribers.Notify(ev)
+	return ev
+}
+
+func (c *Client) wait(expired <-chan time.Time) *mpv.Event {
+	select {
+	case ev := <-c.events:
+		return ev
+	default:
+	}
+
+	select {
+	case ev := <-c.events:
+		return ev
+	case <-c.done:
+		select {
+		case ev := <-c.events:
+			return ev
+		default:
+			return &mpv.Event{EventID: mpv.EventShutdown}
+		}
+	case <-expired:
+		return &mpv.Event{EventID: mpv.EventNone}
+	}
+}
+
+// Events starts goroutine which sends events of the client to returned channel.
+//
+// Channel is closed after EventShutdown or when ctx is cancelled.
+// Only one reader per client is allowed and EventWait must not be called while it is running.
+func (c *Client) Events(ctx context.Context) (<-chan *mpv.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reading {
+		return nil, ErrEventsRunning
+	}
+	c.reading = true
+
+	out := make(chan *mpv.Event)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.reading = false
+			c.mu.Unlock()
+			close(out)
+		}()
+
+		for {
+			var ev *mpv.Event
+			select {
+			case ev = <-c.events:
+			case <-c.done:
+				// returns queued events first, then EventShutdown
+				ev = c.wait(nil)
+			case <-ctx.Done():
+				return
+			}
+
+			c.subscribers.Notify(ev)
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+			if ev.EventID == mpv.EventShutdown {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
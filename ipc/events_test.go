@@ -0,0 +1,173 @@
+package ipc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	observed := func(id uint64) mpv.Format {
+		switch id {
+		case 1:
+			return mpv.FormatInt64
+		case 2:
+			return mpv.FormatDouble
+		case 3:
+			return mpv.FormatString
+		}
+		return mpv.FormatNode
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want *mpv.Event
+	}{
+		{
+			name: "idle",
+			raw:  `{"event":"idle"}`,
+			want: &mpv.Event{EventID: mpv.EventIdle},
+		},
+		{
+			name: "log-message",
+			raw:  `{"event":"log-message","prefix":"cplayer","level":"warn","text":"text\n"}`,
+			want: &mpv.Event{EventID: mpv.EventLogMessage, Data: mpv.ELogMessage{Prefix: "cplayer", Text: "text\n", Level: mpv.LogLevelWarn}},
+		},
+		{
+			name: "client-message",
+			raw:  `{"event":"client-message","args":["key-binding","a","d-"]}`,
+			want: &mpv.Event{EventID: mpv.EventClientMessage, Data: mpv.EClientMessage{"key-binding", "a", "d-"}},
+		},
+		{
+			name: "start-file",
+			raw:  `{"event":"start-file","playlist_entry_id":5}`,
+			want: &mpv.Event{EventID: mpv.EventStartFile, Data: mpv.EStartFile(5)},
+		},
+		{
+			name: "end-file error",
+			raw:  `{"event":"end-file","reason":"error","playlist_entry_id":5,"file_error":"loading failed"}`,
+			want: &mpv.Event{EventID: mpv.EventEndFile, Data: mpv.EEndFile{
+				PlaylistEntryID: 5,
+				Error:           mpv.ErrLoadingFailed,
+				Reason:          mpv.EndFileReasonError,
+			}},
+		},
+		{
+			name: "end-file redirect",
+			raw:  `{"event":"end-file","reason":"redirect","playlist_entry_id":1,"playlist_insert_id":2,"playlist_insert_num_entries":3}`,
+			want: &mpv.Event{EventID: mpv.EventEndFile, Data: mpv.EEndFile{
+				PlaylistEntryID:       1,
+				PlaylistInsertID:      2,
+				PlaylistInsertEntries: 3,
+				Reason:                mpv.EndFileReasonRedirect,
+			}},
+		},
+		{
+			name: "end-file unknown reason",
+			raw:  `{"event":"end-file","reason":"new-reason","playlist_entry_id":1}`,
+			want: &mpv.Event{EventID: mpv.EventEndFile, Data: mpv.EEndFile{PlaylistEntryID: 1, Reason: -1}},
+		},
+		{
+			name: "property-change int64",
+			raw:  `{"event":"property-change","id":1,"name":"playlist-pos","data":2}`,
+			want: &mpv.Event{ID: 1, EventID: mpv.EventPropertyChange, Data: mpv.EProperty{Name: "playlist-pos", Format: mpv.FormatInt64, Property: int64(2)}},
+		},
+		{
+			name: "property-change double from integer",
+			raw:  `{"event":"property-change","id":2,"name":"volume","data":100}`,
+			want: &mpv.Event{ID: 2, EventID: mpv.EventPropertyChange, Data: mpv.EProperty{Name: "volume", Format: mpv.FormatDouble, Property: 100.0}},
+		},
+		{
+			name: "property-change string",
+			raw:  `{"event":"property-change","id":3,"name":"path","data":"a.mkv"}`,
+			want: &mpv.Event{ID: 3, EventID: mpv.EventPropertyChange, Data: mpv.EProperty{Name: "path", Format: mpv.FormatString, Property: "a.mkv"}},
+		},
+		{
+			name: "property-change node",
+			raw:  `{"event":"property-change","id":4,"name":"metadata","data":{"title":"a"}}`,
+			want: &mpv.Event{ID: 4, EventID: mpv.EventPropertyChange, Data: mpv.EProperty{Name: "metadata", Format: mpv.FormatNode, Property: &mpv.Node{
+				Format: mpv.FormatNodeMap,
+				Data:   mpv.NodeMap{"title": {Data: "a", Format: mpv.FormatString}},
+			}}},
+		},
+		{
+			name: "property-change mismatched format",
+			raw:  `{"event":"property-change","id":1,"name":"playlist-pos","data":"no"}`,
+			want: &mpv.Event{ID: 1, EventID: mpv.EventPropertyChange, Data: mpv.EProperty{Name: "playlist-pos", Format: mpv.FormatNode, Property: &mpv.Node{Data: "no", Format: mpv.FormatString}}},
+		},
+		{
+			name: "property-change unavailable",
+			raw:  `{"event":"property-change","id":1,"name":"playlist-pos"}`,
+			want: &mpv.Event{ID: 1, EventID: mpv.EventPropertyChange, Data: mpv.EProperty{Name: "playlist-pos", Format: mpv.FormatNone}},
+		},
+		{
+			name: "hook",
+			raw:  `{"event":"hook","id":7,"name":"on_load","hook_id":12}`,
+			want: &mpv.Event{ID: 7, EventID: mpv.EventHook, Data: mpv.EHook{Name: "on_load", ID: 12}},
+		},
+		{
+			name: "event with error",
+			raw:  `{"event":"command-reply","id":9,"error":"error running command"}`,
+			want: &mpv.Event{ID: 9, Error: mpv.ErrCommand, EventID: mpv.EventCommandReply},
+		},
+		{
+			name: "unknown event",
+			raw:  `{"event":"new-event"}`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg message
+			if err := json.Unmarshal([]byte(tt.raw), &msg); err != nil {
+				t.Fatal(err)
+			}
+
+			ev := decodeEvent(msg.Event, json.RawMessage(tt.raw), observed)
+			if !reflect.DeepEqual(ev, tt.want) {
+				t.Errorf("got %#v, want %#v", ev, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want mpv.Error
+	}{
+		{"success", mpv.ErrSuccess},
+		{"event queue full", mpv.ErrEventQueueFull},
+		{"invalid parameter", mpv.ErrInvalidParameter},
+		{"property not found", mpv.ErrPropertyNotFound},
+		{"property unavailable", mpv.ErrPropertyUnavailable},
+		{"unsupported format for accessing property", mpv.ErrPropertyFormat},
+		{"error running command", mpv.ErrCommand},
+		{"loading failed", mpv.ErrLoadingFailed},
+		{"operation not implemented", mpv.ErrNotImplemented},
+		{"something happened", mpv.ErrGeneric},
+		{"invalid JSON", mpv.ErrGeneric},
+		{"", mpv.ErrGeneric},
+	}
+
+	for _, tt := range tests {
+		if got := parseError(tt.msg); got != tt.want {
+			t.Errorf("parseError(%q) = %d, want %d", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestEventName(t *testing.T) {
+	for name, id := range eventNames {
+		if got := eventName(id); got != name {
+			t.Errorf("eventName(%d) = %q, want %q", id, got, name)
+		}
+	}
+	if got := eventName(mpv.EventNone); got != "" {
+		t.Errorf("eventName(EventNone) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,150 @@
+package mpv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Chapter is single entry of "chapter-list" property
+type Chapter struct {
+	Title string        `mpv:"title"`
+	Time  time.Duration `mpv:"time"`
+}
+
+// Edition is single entry of "edition-list" property
+type Edition struct {
+	ID      int64  `mpv:"id"`
+	Title   string `mpv:"title"`
+	Default bool   `mpv:"default"`
+}
+
+// Chapters manages chapters and editions of current file
+type Chapters struct {
+	p Player
+}
+
+// NewChapters returns manager of chapters of p
+func NewChapters(p Player) *Chapters {
+	return &Chapters{p: p}
+}
+
+// List returns chapters of current file
+func (c *Chapters) List() ([]Chapter, error) {
+	node, err := getNode(c.p, "chapter-list")
+	if err != nil {
+		return nil, err
+	}
+
+	var chapters []Chapter
+	if err := Unmarshal(node, &chapters); err != nil {
+		return nil, &OpError{Op: "get_property", Name: "chapter-list", Code: ErrPropertyFormat, Err: err}
+	}
+	return chapters, nil
+}
+
+// Editions returns editions of current file
+func (c *Chapters) Editions() ([]Edition, error) {
+	node, err := getNode(c.p, "edition-list")
+	if err != nil {
+		return nil, err
+	}
+
+	var editions []Edition
+	if err := Unmarshal(node, &editions); err != nil {
+		return nil, &OpError{Op: "get_property", Name: "edition-list", Code: ErrPropertyFormat, Err: err}
+	}
+	return editions, nil
+}
+
+// Current returns index of current chapter, -1 before the first chapter
+func (c *Chapters) Current() (int, error) {
+	chapter, err := getInt64(c.p, "chapter")
+	return int(chapter), err
+}
+
+// Seek seeks to the start of chapter with provided index
+func (c *Chapters) Seek(n int) error {
+	return c.p.SetProperty("chapter", int64(n), FormatInt64)
+}
+
+// Next seeks to the next chapter
+func (c *Chapters) Next() error {
+	return c.p.Command([]string{"add", "chapter", "1"})
+}
+
+// Prev seeks to the previous chapter (or start of current one, depending on "chapter-seek-threshold")
+func (c *Chapters) Prev() error {
+	return c.p.Command([]string{"add", "chapter", "-1"})
+}
+
+// SetEdition switches to edition with provided ID, which reloads current file
+func (c *Chapters) SetEdition(id int64) error {
+	return c.p.SetProperty("edition", id, FormatInt64)
+}
+
+// OnChange calls fn with index of current chapter every time it changes.
+// Index is -1 before the first chapter or when there is no file. Player must implement Subscriber.
+func (c *Chapters) OnChange(fn func(chapter int)) (io.Closer, error) {
+	return subscribe(c.p, "chapter", FormatInt64, func(e EProperty) {
+		chapter, ok := e.Property.(int64)
+		if !ok {
+			chapter = -1
+		}
+		fn(int(chapter))
+	})
+}
+
+// SetFile writes chapters into file at path and sets "chapters-file" option,
+// so they replace chapters of files loaded from now on. File must exist until the file is loaded.
+//
+// duration is length of the media, it is used as end of the last chapter.
+func (c *Chapters) SetFile(path string, chapters []Chapter, duration time.Duration) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteChapters(f, chapters, duration); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return c.p.SetPropertyString("chapters-file", path)
+}
+
+// WriteChapters writes chapters in FFMETADATA format, which can be used as "chapters-file".
+// Chapters must be sorted by time. End of the last chapter is duration, if it is greater than its start.
+func WriteChapters(w io.Writer, chapters []Chapter, duration time.Duration) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, ";FFMETADATA1")
+
+	for i, c := range chapters {
+		end := c.Time
+		if i+1 < len(chapters) {
+			end = chapters[i+1].Time
+		} else if duration > end {
+			end = duration
+		}
+		if end < c.Time {
+			return fmt.Errorf("chapters are not sorted by time: %v after %v", chapters[i+1].Time, c.Time)
+		}
+
+		fmt.Fprintln(bw, "[CHAPTER]")
+		fmt.Fprintln(bw, "TIMEBASE=1/1000")
+		fmt.Fprintf(bw, "START=%d\n", c.Time.Milliseconds())
+		fmt.Fprintf(bw, "END=%d\n", end.Milliseconds())
+		fmt.Fprintf(bw, "title=%s\n", escapeMetadata(c.Title))
+	}
+	return bw.Flush()
+}
+
+var metadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+func escapeMetadata(value string) string {
+	return metadataEscaper.Replace(value)
+}
@@ -0,0 +1,47 @@
+package mpv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteChapters(t *testing.T) {
+	chapters := []Chapter{
+		{Title: "Intro", Time: 0},
+		{Title: "a=b; #1\\2", Time: 90 * time.Second},
+		{Title: "Line\nbreak", Time: 150500 * time.Millisecond},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteChapters(&buf, chapters, 3*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ";FFMETADATA1\n" +
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=90000\ntitle=Intro\n" +
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=90000\nEND=150500\ntitle=a\\=b\\; \\#1\\\\2\n" +
+		"[CHAPTER]\nTIMEBASE=1/1000\nSTART=150500\nEND=180000\ntitle=Line\\\nbreak\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteChaptersShortDuration(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteChapters(&buf, []Chapter{{Title: "a", Time: 10 * time.Second}}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ";FFMETADATA1\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=10000\nEND=10000\ntitle=a\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteChaptersUnsorted(t *testing.T) {
+	chapters := []Chapter{{Time: 20 * time.Second}, {Time: 10 * time.Second}}
+	if err := WriteChapters(&bytes.Buffer{}, chapters, 0); err == nil {
+		t.Error("expected error for unsorted chapters")
+	}
+}
@@ -0,0 +1,28 @@
+package mpv
+
+// CommandName returns name of command passed as list of arguments, used in errors
+func CommandName(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// CommandNodeName returns name of command passed as Node (list of arguments or map with "name" key),
+// used in errors
+func CommandNodeName(args *Node) string {
+	if args == nil {
+		return ""
+	}
+	switch v := args.Data.(type) {
+	case NodeList:
+		if len(v) > 0 {
+			name, _ := v[0].Data.(string)
+			return name
+		}
+	case NodeMap:
+		name, _ := v["name"].Data.(string)
+		return name
+	}
+	return ""
+}
@@ -1,7 +1,5 @@
 package mpv
 
-import "errors"
-
 // Format represents supported formats by mpv API
 // used for retrieving and setting options and properties.
 type Format int
@@ -74,57 +72,41 @@ const (
 	EndFileReasonRedirect EndFileReason = 5
 )
 
-// Error this is not used anywhere, but maybe someone finds use case for it
+// ScreenshotMode decides what is included in the screenshot
+type ScreenshotMode string
+
+const (
+	ScreenshotSubtitles ScreenshotMode = "subtitles" // video with subtitles (mpv default)
+	ScreenshotVideo     ScreenshotMode = "video"     // video without subtitles and OSD
+	ScreenshotWindow    ScreenshotMode = "window"    // content of the window, with scaling, OSD and subtitles
+)
+
+// Error is error code returned by mpv.
 //
-// Every error code is wrapped with go standard error package
+// Codes are comparable with errors.Is, e.g. errors.Is(err, ErrPropertyUnavailable).
+// Functions of this package return them wrapped in *OpError.
 type Error int
 
 const (
-	ErrSuccess             = 0
-	ErrEventQueueFull      = -1
-	ErrNomem               = -2
-	ErrUninitialized       = -3
-	ErrInvalidParameter    = -4
-	ErrOptionNotFound      = -5
-	ErrOptionFormat        = -6
-	ErrOptionError         = -7
-	ErrPropertyNotFound    = -8
-	ErrPropertyFormat      = -9
-	ErrPropertyUnavailable = -10
-	ErrPropertyError       = -11
-	ErrCommand             = -12
-	ErrLoadingFailed       = -13
-	ErrAoInitFailed        = -14
-	ErrVoInitFailed        = -15
-	ErrNothingToPlay       = -16
-	ErrUnknownFormat       = -17
-	ErrUnsupported         = -18
-	ErrNotImplemented      = -19
-	ErrGeneric             = -20
+	ErrSuccess             Error = 0
+	ErrEventQueueFull      Error = -1
+	ErrNomem               Error = -2
+	ErrUninitialized       Error = -3
+	ErrInvalidParameter    Error = -4
+	ErrOptionNotFound      Error = -5
+	ErrOptionFormat        Error = -6
+	ErrOptionError         Error = -7
+	ErrPropertyNotFound    Error = -8
+	ErrPropertyFormat      Error = -9
+	ErrPropertyUnavailable Error = -10
+	ErrPropertyError       Error = -11
+	ErrCommand             Error = -12
+	ErrLoadingFailed       Error = -13
+	ErrAoInitFailed        Error = -14
+	ErrVoInitFailed        Error = -15
+	ErrNothingToPlay       Error = -16
+	ErrUnknownFormat       Error = -17
+	ErrUnsupported         Error = -18
+	ErrNotImplemented      Error = -19
+	ErrGeneric             Error = -20
 )
-
-func (e Error) Err() error {
-	return []error{
-		nil,
-		errors.New("event ringbuffer is full and can't receive any events"),
-		errors.New("memory allocation failed"),
-		errors.New("mpv core is not initialized"),
-		errors.New("invalid or unsupported parameter value"),
-		errors.New("option does not exists"),
-		errors.New("unsupported FORMAT of option"),
-		errors.New("provided option value could not be parsed"),
-		errors.New("accessed property does not exist"),
-		errors.New("usage of unsupported FORMAT"),
-		errors.New("property exists, but is currently unavailable"),
-		errors.New("something went wrong when setting or getting a property"),
-		errors.New("something went wrong while running a command"),
-		errors.New("something went wrong while loading"),
-		errors.New("initialization of audio output failed"),
-		errors.New("initialization of video output failed"),
-		errors.New("there is no audio or video data to play"),
-		errors.New("cannot identify file format"),
-		errors.New("system requirements not fulfilled"),
-		errors.New("function is not implemented"),
-		errors.New("unknown error occurred"),
-	}[-e]
-}
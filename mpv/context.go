@@ -0,0 +1,201 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrLoadInterrupted is returned by LoadFileContext when file was stopped or replaced before it was loaded
+var ErrLoadInterrupted = errors.New("loading of the file was interrupted")
+
+// loadReplyTimeout limits how long cancelled LoadFileContext waits for reply of loadfile
+const loadReplyTimeout = time.Second
+
+// CommandContext runs command asynchronously and waits for its result.
+// When ctx is done before the command finishes, the command is aborted with AbortAsyncCommand
+// and ctx.Err() is returned. Only some commands can be aborted (e.g. "subprocess").
+//
+// Events must be read (EventWait or Events) for the command to complete.
+func (m *Mpv) CommandContext(ctx context.Context, args []string) (*Node, error) {
+	f, err := m.CommandFuture(args)
+	if err != nil {
+		return nil, err
+	}
+	return m.waitCommand(ctx, f)
+}
+
+// CommandNodeContext is like CommandContext, but takes arguments as Node.
+func (m *Mpv) CommandNodeContext(ctx context.Context, args *Node) (*Node, error) {
+	f, err := m.CommandNodeFuture(args)
+	if err != nil {
+		return nil, err
+	}
+	return m.waitCommand(ctx, f)
+}
+
+func (m *Mpv) waitCommand(ctx context.Context, f *Future) (*Node, error) {
+	select {
+	case <-f.Done():
+		return f.result, f.err
+	case <-ctx.Done():
+		m.AbortAsyncCommand(f.ID())
+		return nil, ctx.Err()
+	}
+}
+
+// LoadFileContext replaces current file with url and waits until it is loaded (EventFileLoaded).
+//
+// If loading fails, error of EventEndFile is returned. When ctx is done before the file
+// is loaded, the entry is removed from playlist and ctx.Err() is returned.
+// loadfile cannot be aborted, so if ctx is done before its reply, the reply is awaited
+// for up to a second to find out the new entry. If it does not arrive in time, playback is stopped instead.
+// Events must be read (EventWait or Events) for this function to complete.
+func (m *Mpv) LoadFileContext(ctx context.Context, url string) error {
+	var mu sync.Mutex
+	var pending []*Event
+	notify := make(chan struct{}, 1)
+
+	remove := m.state.addListener(func(ev *Event) {
+		switch ev.EventID {
+		case EventStartFile, EventEndFile, EventFileLoaded:
+		default:
+			return
+		}
+
+		mu.Lock()
+		pending = append(pending, ev)
+		mu.Unlock()
+
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	defer remove()
+
+	f, err := m.CommandFuture([]string{"loadfile", url, "replace"})
+	if err != nil {
+		return err
+	}
+
+	// entryID is -1 when mpv does not report ID of the new entry,
+	// then the first started file is assumed to be ours.
+	entryID := int64(-1)
+	replied := f.Done()
+	started := false
+
+	for {
+		select {
+		case <-replied:
+			replied = nil
+			if f.err != nil {
+				return f.err
+			}
+			entryID = loadedEntryID(f)
+		case <-notify:
+		case <-ctx.Done():
+			switch {
+			case replied != nil:
+				timer := time.NewTimer(loadReplyTimeout)
+				select {
+				case <-replied:
+					if f.err == nil {
+						m.removeEntry(loadedEntryID(f))
+					}
+				case <-timer.C:
+					m.removeEntry(-1)
+				}
+				timer.Stop()
+			case started:
+				_ = m.Command([]string{"playlist-remove", "current"})
+			default:
+				// entry was already added by loadfile, but it has not started yet
+				m.removeEntry(entryID)
+			}
+			return ctx.Err()
+		}
+
+		// Events can arrive before the reply, so they are processed only after it
+		if replied != nil {
+			continue
+		}
+
+		mu.Lock()
+		events := pending
+		pending = nil
+		mu.Unlock()
+
+		for _, ev := range events {
+			switch ev.EventID {
+			case EventStartFile:
+				id := int64(ev.Data.(EStartFile))
+				if entryID == -1 {
+					entryID = id
+				}
+				started = id == entryID
+			case EventFileLoaded:
+				if started {
+					return nil
+				}
+			case EventEndFile:
+				end := ev.Data.(EEndFile)
+				if end.PlaylistEntryID != entryID {
+					continue
+				}
+
+				switch end.Reason {
+				case EndFileReasonError:
+					return &OpError{Op: "loadfile", Name: url, Code: end.Error}
+				case EndFileReasonRedirect:
+					// playlist was expanded, wait for its first entry
+					entryID = end.PlaylistInsertID
+					started = false
+				default:
+					return ErrLoadInterrupted
+				}
+			}
+		}
+	}
+}
+
+// loadedEntryID returns ID of the entry added by successful loadfile, -1 if mpv did not report it
+func loadedEntryID(f *Future) int64 {
+	if f.result == nil {
+		return -1
+	}
+	if values, ok := f.result.Data.(NodeMap); ok {
+		if id, ok := values["playlist_entry_id"].Data.(int64); ok {
+			return id
+		}
+	}
+	return -1
+}
+
+// removeEntry removes playlist entry with provided ID. When ID is unknown (-1),
+// playback is stopped instead, which removes the only entry left after "loadfile replace".
+func (m *Mpv) removeEntry(id int64) {
+	if id == -1 {
+		_ = m.Command([]string{"stop"})
+		return
+	}
+
+	node, err := m.GetNode("playlist")
+	if err != nil {
+		return
+	}
+	entries, err := decodePlaylist(node)
+	if err != nil {
+		return
+	}
+	for i, entry := range entries {
+		if entry.ID == id {
+			_ = m.Command([]string{"playlist-remove", strconv.Itoa(i)})
+			return
+		}
+	}
+}
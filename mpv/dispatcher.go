@@ -0,0 +1,139 @@
+package mpv
+
+import (
+	"sync"
+)
+
+// Dispatcher calls registered handlers for received events.
+//
+// Every On* method returns function which unsubscribes the handler.
+// Handlers are called synchronously from goroutine which calls Dispatch.
+// Zero value is ready to use.
+type Dispatcher struct {
+	// PanicHandler is called when handler panics. Panic is recovered
+	// and other handlers are still called, even if PanicHandler is nil.
+	PanicHandler func(ev *Event, recovered interface{})
+
+	mu       sync.Mutex
+	handlers map[EventID][]*eventHandler
+}
+
+type eventHandler struct {
+	fn      func(ev *Event)
+	removed bool
+}
+
+// NewDispatcher creates dispatcher without any handlers
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: map[EventID][]*eventHandler{}}
+}
+
+// On registers handler for every event with provided id
+func (d *Dispatcher) On(event EventID, fn func(ev *Event)) (unsubscribe func()) {
+	h := &eventHandler{fn: fn}
+
+	d.mu.Lock()
+	if d.handlers == nil {
+		d.handlers = map[EventID][]*eventHandler{}
+	}
+	d.handlers[event] = append(d.handlers[event], h)
+	d.mu.Unlock()
+
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		h.removed = true
+		list := d.handlers[event]
+		for i, v := range list {
+			if v == h {
+				d.handlers[event] = append(list[:i:i], list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// OnStartFile registers handler for EventStartFile
+func (d *Dispatcher) OnStartFile(fn func(EStartFile)) (unsubscribe func()) {
+	return d.On(EventStartFile, func(ev *Event) {
+		fn(ev.Data.(EStartFile))
+	})
+}
+
+// OnEndFile registers handler for EventEndFile
+func (d *Dispatcher) OnEndFile(fn func(EEndFile)) (unsubscribe func()) {
+	return d.On(EventEndFile, func(ev *Event) {
+		fn(ev.Data.(EEndFile))
+	})
+}
+
+// OnLogMessage registers handler for EventLogMessage.
+// Log messages must be enabled with RequestLogMessages.
+func (d *Dispatcher) OnLogMessage(fn func(ELogMessage)) (unsubscribe func()) {
+	return d.On(EventLogMessage, func(ev *Event) {
+		fn(ev.Data.(ELogMessage))
+	})
+}
+
+// OnClientMessage registers handler for EventClientMessage
+func (d *Dispatcher) OnClientMessage(fn func(EClientMessage)) (unsubscribe func()) {
+	return d.On(EventClientMessage, func(ev *Event) {
+		fn(ev.Data.(EClientMessage))
+	})
+}
+
+// OnHook registers handler for EventHook.
+// Handler is responsible for calling HookContinue.
+func (d *Dispatcher) OnHook(fn func(EHook)) (unsubscribe func()) {
+	return d.On(EventHook, func(ev *Event) {
+		fn(ev.Data.(EHook))
+	})
+}
+
+// OnCommandReply registers handler for EventCommandReply.
+// To check whether the command failed, use On and Event.Error instead.
+func (d *Dispatcher) OnCommandReply(fn func(ECommandReply)) (unsubscribe func()) {
+	return d.On(EventCommandReply, func(ev *Event) {
+		fn(ev.Data.(ECommandReply))
+	})
+}
+
+// OnPropertyChange registers handler for EventPropertyChange of property with provided name.
+// Property must be observed with ObserveProperty.
+func (d *Dispatcher) OnPropertyChange(name string, fn func(EProperty)) (unsubscribe func()) {
+	return d.On(EventPropertyChange, func(ev *Event) {
+		if prop := ev.Data.(EProperty); prop.Name == name {
+			fn(prop)
+		}
+	})
+}
+
+// Dispatch calls all handlers registered for the event.
+// Handlers unsubscribed by other handlers of the same event are not called.
+func (d *Dispatcher) Dispatch(ev *Event) {
+	d.mu.Lock()
+	list := d.handlers[ev.EventID]
+	d.mu.Unlock()
+
+	for _, h := range list {
+		d.mu.Lock()
+		removed := h.removed
+		d.mu.Unlock()
+
+		if !removed {
+			d.call(h, ev)
+		}
+	}
+}
+
+// Run dispatches events from channel (e.g. returned by Mpv.Events) until it is closed
+func (d *Dispatcher) Run(events <-chan *Event) {
+	for ev := range events {
+		d.Dispatch(ev)
+	}
+}
+
+func (d *Dispatcher) call(h *eventHandler, ev *Event) {
+	callRecovered(ev, d.PanicHandler, func() { h.fn(ev) })
+}
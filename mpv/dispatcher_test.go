@@ -0,0 +1,90 @@
+package mpv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDispatcherZeroValue(t *testing.T) {
+	var recovered interface{}
+	d := &Dispatcher{PanicHandler: func(ev *Event, r interface{}) { recovered = r }}
+
+	called := false
+	d.On(EventShutdown, func(ev *Event) { called = true })
+	d.Dispatch(&Event{EventID: EventShutdown})
+
+	if !called {
+		t.Error("handler was not called")
+	}
+	if recovered != nil {
+		t.Errorf("unexpected panic: %v", recovered)
+	}
+}
+
+func TestDispatcherUnsubscribe(t *testing.T) {
+	d := NewDispatcher()
+
+	var calls []string
+	var unsubscribeB func()
+	unsubscribeA := d.On(EventVideoReconfig, func(ev *Event) {
+		calls = append(calls, "a")
+		unsubscribeB()
+	})
+	unsubscribeB = d.On(EventVideoReconfig, func(ev *Event) { calls = append(calls, "b") })
+	d.On(EventVideoReconfig, func(ev *Event) { calls = append(calls, "c") })
+
+	d.Dispatch(&Event{EventID: EventVideoReconfig})
+	if want := []string{"a", "c"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+
+	calls = nil
+	unsubscribeA()
+	unsubscribeA() // second call does nothing
+	d.Dispatch(&Event{EventID: EventVideoReconfig})
+	if want := []string{"c"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls after unsubscribe = %q, want %q", calls, want)
+	}
+}
+
+func TestDispatcherPanic(t *testing.T) {
+	d := NewDispatcher()
+
+	var recovered interface{}
+	var recoveredEvent *Event
+	d.PanicHandler = func(ev *Event, r interface{}) {
+		recoveredEvent, recovered = ev, r
+	}
+
+	called := false
+	d.On(EventFileLoaded, func(ev *Event) { panic("handler failed") })
+	d.On(EventFileLoaded, func(ev *Event) { called = true })
+
+	ev := &Event{EventID: EventFileLoaded}
+	d.Dispatch(ev)
+
+	if recovered != "handler failed" || recoveredEvent != ev {
+		t.Errorf("PanicHandler got (%v, %v), want (%v, handler failed)", recoveredEvent, recovered, ev)
+	}
+	if !called {
+		t.Error("handler after panicking one was not called")
+	}
+
+	d.PanicHandler = nil
+	d.Dispatch(ev) // panic is recovered without handler
+}
+
+func TestDispatcherPropertyChange(t *testing.T) {
+	d := NewDispatcher()
+
+	var changes []EProperty
+	d.OnPropertyChange("volume", func(e EProperty) { changes = append(changes, e) })
+
+	d.Dispatch(&Event{EventID: EventPropertyChange, Data: EProperty{Name: "pause", Property: true, Format: FormatFlag}})
+	d.Dispatch(&Event{EventID: EventPropertyChange, Data: EProperty{Name: "volume", Property: 50.0, Format: FormatDouble}})
+
+	want := []EProperty{{Name: "volume", Property: 50.0, Format: FormatDouble}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %#v, want %#v", changes, want)
+	}
+}
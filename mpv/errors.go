@@ -0,0 +1,43 @@
+package mpv
+
+import "errors"
+
+// Err returns nil for success (and other non-negative codes), otherwise the code itself.
+func (e Error) Err() error {
+	if e >= 0 {
+		return nil
+	}
+	return e
+}
+
+// OpError is returned by functions of this package when mpv reports error.
+//
+// Use errors.Is with Error codes to check the reason, e.g. errors.Is(err, ErrPropertyUnavailable).
+type OpError struct {
+	Op   string // Op is mpv operation, e.g. "set_property" or "command"
+	Name string // Name of property, option or command, if any
+	Code Error
+	Err  error // Err is optional error with more details, when the error was detected by this package
+}
+
+func (e *OpError) Error() string {
+	msg := "mpv " + e.Op
+	if e.Name != "" {
+		msg += " '" + e.Name + "'"
+	}
+	msg += ": " + e.Code.Error()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns error code, so OpError can be compared with errors.Is
+func (e *OpError) Unwrap() error {
+	return e.Code
+}
+
+// Is reports whether Err matches target, so errors.Is works with both the code and Err
+func (e *OpError) Is(target error) bool {
+	return e.Err != nil && errors.Is(e.Err, target)
+}
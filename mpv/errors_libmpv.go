@@ -0,0 +1,19 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+// #include "utils.h"
+import "C"
+
+// Error returns description of the error code provided by mpv
+func (e Error) Error() string {
+	return C.GoString(C.mpv_error_string(C.int(e)))
+}
+
+// newOpError returns *OpError for negative codes and nil otherwise
+func newOpError(op, name string, code C.int) error {
+	if code >= 0 {
+		return nil
+	}
+	return &OpError{Op: op, Name: name, Code: Error(code)}
+}
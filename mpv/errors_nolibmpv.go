@@ -0,0 +1,37 @@
+//go:build !cgo || nolibmpv
+
+package mpv
+
+// errorStrings are the same as returned by mpv_error_string
+var errorStrings = map[Error]string{
+	ErrSuccess:             "success",
+	ErrEventQueueFull:      "event queue full",
+	ErrNomem:               "memory allocation failed",
+	ErrUninitialized:       "core not uninitialized",
+	ErrInvalidParameter:    "invalid parameter",
+	ErrOptionNotFound:      "option not found",
+	ErrOptionFormat:        "unsupported format for accessing option",
+	ErrOptionError:         "error setting option",
+	ErrPropertyNotFound:    "property not found",
+	ErrPropertyFormat:      "unsupported format for accessing property",
+	ErrPropertyUnavailable: "property unavailable",
+	ErrPropertyError:       "error accessing property",
+	ErrCommand:             "error running command",
+	ErrLoadingFailed:       "loading failed",
+	ErrAoInitFailed:        "audio output initialization failed",
+	ErrVoInitFailed:        "video output initialization failed",
+	ErrNothingToPlay:       "no audio or video data played",
+	ErrUnknownFormat:       "unrecognized file format",
+	ErrUnsupported:         "not supported",
+	ErrNotImplemented:      "operation not implemented",
+	ErrGeneric:             "something happened",
+}
+
+// Error returns description of the error code.
+// Without libmpv it uses copy of messages from mpv_error_string.
+func (e Error) Error() string {
+	if msg, ok := errorStrings[e]; ok {
+		return msg
+	}
+	return "unknown error"
+}
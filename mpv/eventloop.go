@@ -0,0 +1,195 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Backpressure decides what happens with events when consumer of Events channel
+// is slower than mpv.
+type Backpressure int
+
+const (
+	// BackpressureBlock stops reading new events until consumer catches up.
+	// mpv may drop events if its internal queue overflows (EventQueueOverflow).
+	BackpressureBlock Backpressure = iota
+	// BackpressureDropOldest drops the oldest pending event when buffer is full.
+	BackpressureDropOldest
+	// BackpressureCoalesce keeps only the latest pending change of every observed property.
+	// Other events are handled as with BackpressureBlock.
+	BackpressureCoalesce
+)
+
+// EventsOptions configures event channel returned by EventsWithOptions
+type EventsOptions struct {
+	Buffer       int // Buffer is number of pending events, 64 if not set
+	Backpressure Backpressure
+}
+
+// ErrEventsRunning is returned when events of the handle are already consumed by other reader
+var ErrEventsRunning = errors.New("events of this handle are already consumed by other reader")
+
+type eventLoop struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Events starts goroutine which reads events of the handle and sends them to returned channel.
+// It uses BackpressureBlock policy.
+//
+// Channel is closed after EventShutdown or when ctx is cancelled.
+// Only one reader per handle is allowed and EventWait must not be called while it is running.
+func (m *Mpv) Events(ctx context.Context) (<-chan *Event, error) {
+	return m.EventsWithOptions(ctx, EventsOptions{})
+}
+
+// EventsWithOptions is like Events, but allows to configure buffering and backpressure policy.
+func (m *Mpv) EventsWithOptions(ctx context.Context, opts EventsOptions) (<-chan *Event, error) {
+	if opts.Buffer <= 0 {
+		opts.Buffer = 64
+	}
+
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+	if m.state.events != nil {
+		return nil, ErrEventsRunning
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	loop := &eventLoop{cancel: cancel, done: make(chan struct{})}
+	m.state.events = loop
+
+	out := make(chan *Event)
+	go m.runEventLoop(ctx, loop, opts, out)
+	return out, nil
+}
+
+func (m *Mpv) runEventLoop(ctx context.Context, loop *eventLoop, opts EventsOptions, out chan<- *Event) {
+	in := make(chan *Event)
+	readerDone := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(readerDone)
+		defer close(in)
+		m.readEvents(ctx, in)
+	}()
+	go func() {
+		// EventWait blocks in C, so it must be woken up when ctx is cancelled
+		defer wg.Done()
+		select {
+		case <-ctx.Done():
+			m.Wakeup()
+		case <-readerDone:
+		}
+	}()
+
+	queueEvents(ctx, in, out, opts)
+	loop.cancel()
+	wg.Wait()
+
+	m.state.mu.Lock()
+	if m.state.events == loop {
+		m.state.events = nil
+	}
+	m.state.mu.Unlock()
+
+	close(out)
+	close(loop.done)
+}
+
+func (m *Mpv) readEvents(ctx context.Context, in chan<- *Event) {
+	for {
+		ev := m.EventWait(-1)
+		if ctx.Err() != nil {
+			return
+		}
+		if ev.EventID == EventNone {
+			continue
+		}
+
+		select {
+		case in <- ev:
+		case <-ctx.Done():
+			return
+		}
+		if ev.EventID == EventShutdown {
+			return
+		}
+	}
+}
+
+func queueEvents(ctx context.Context, in <-chan *Event, out chan<- *Event, opts EventsOptions) {
+	var queue []*Event
+	for {
+		recv := in
+		if len(queue) >= opts.Buffer && opts.Backpressure != BackpressureDropOldest {
+			recv = nil
+		}
+
+		var send chan<- *Event
+		var next *Event
+		if len(queue) > 0 {
+			send = out
+			next = queue[0]
+		}
+
+		if in == nil && send == nil {
+			return
+		}
+
+		select {
+		case ev, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			queue = pushEvent(queue, ev, opts)
+		case send <- next:
+			queue[0] = nil
+			queue = queue[1:]
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func pushEvent(queue []*Event, ev *Event, opts EventsOptions) []*Event {
+	switch opts.Backpressure {
+	case BackpressureDropOldest:
+		if len(queue) >= opts.Buffer {
+			queue[0] = nil
+			queue = queue[1:]
+		}
+	case BackpressureCoalesce:
+		if ev.EventID != EventPropertyChange {
+			break
+		}
+		name := ev.Data.(EProperty).Name
+		for i, v := range queue {
+			if v.EventID == EventPropertyChange && v.ID == ev.ID && v.Data.(EProperty).Name == name {
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+	}
+	return append(queue, ev)
+}
+
+// stopEventLoop stops event reader of the handle and waits until it exits
+func (m *Mpv) stopEventLoop() {
+	m.state.mu.Lock()
+	loop := m.state.events
+	m.state.mu.Unlock()
+
+	if loop == nil {
+		return
+	}
+	loop.cancel()
+	<-loop.done
+}
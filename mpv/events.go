@@ -1,50 +1,15 @@
+//go:build cgo && !nolibmpv
+
 package mpv
 
 // #include "utils.h"
 // #include <stdlib.h>
 import "C"
 import (
+	"runtime/cgo"
 	"unsafe"
 )
 
-type Event struct {
-	ID      uint64
-	Error   Error
-	Data    interface{}
-	EventID EventID
-}
-
-type EProperty struct {
-	Name     string
-	Format   Format
-	Property interface{}
-}
-
-type ELogMessage struct {
-	Prefix, Text string
-	Level        LogLevel
-}
-
-type EClientMessage []string
-
-type EStartFile int64
-
-type EEndFile struct {
-	PlaylistEntryID       int64
-	PlaylistInsertID      int64
-	PlaylistInsertEntries int
-	Error                 Error
-	Reason                EndFileReason
-}
-
-type EHook struct {
-	Name string
-	ID   uint64
-}
-
-type ECommandReply *Node
-
-
 // RequestEvent
 //
 // status = true means enabled, otherwise disabled
@@ -55,7 +20,7 @@ func (m Mpv) RequestEvent(event EventID, status bool) error {
 	}
 
 	code := C.mpv_request_event(m.ctx, C.mpv_event_id(event), cstatus)
-	return Error(code).Err()
+	return newOpError("request_event", EventName(event), code)
 }
 
 func (m Mpv) RequestLogMessages(level LogLevel) error {
@@ -63,15 +28,32 @@ func (m Mpv) RequestLogMessages(level LogLevel) error {
 	defer C.free(unsafe.Pointer(clevel))
 
 	code := C.mpv_request_log_messages(m.ctx, clevel)
-	return Error(code).Err()
+	return newOpError("request_log_messages", level.String(), code)
 }
 
 func (m Mpv) Wakeup() {
 	C.mpv_wakeup(m.ctx)
 }
 
-func (m Mpv) SetWakeupCallback() {
-	panic("[SetWakeupCallback] function not implemented.")
+// SetWakeupCallback sets callback which is called when new event arrives in the queue
+// (for example to wake up the loop which calls EventWait(0)).
+//
+// Callback is called from mpv internal threads, so it must not call any mpv functions
+// and should return quickly. Passing nil removes the callback.
+func (m Mpv) SetWakeupCallback(callback func()) {
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	old := m.state.wakeup
+	m.state.wakeup = 0
+	if callback != nil {
+		m.state.wakeup = cgo.NewHandle(callback)
+	}
+
+	C.setWakeupCallback(m.ctx, C.uintptr_t(m.state.wakeup))
+	if old != 0 {
+		old.Delete()
+	}
 }
 
 func (m Mpv) WaitAsyncRequests() {
@@ -83,17 +65,91 @@ func (m Mpv) HookAdd(name string, priority int, id uint64) error {
 	defer C.free(unsafe.Pointer(cname))
 
 	code := C.mpv_hook_add(m.ctx, C.ulong(id), cname, C.int(priority))
-	return Error(code).Err()
+	return newOpError("hook_add", name, code)
 }
 
 func (m Mpv) HookContinue(id uint64) error {
 	code := C.mpv_hook_continue(m.ctx, C.ulong(id))
-	return Error(code).Err()
+	return newOpError("hook_continue", "", code)
 }
 
+// EventWait waits for next event. Negative timeout means infinite wait.
+//
+// Events are also used to resolve futures and other handlers registered by the library,
+// so they are still returned to the caller afterwards.
 func (m Mpv) EventWait(timeout float64) *Event {
 	cevent := C.mpv_wait_event(m.ctx, C.double(timeout))
-	return decodeCEvent(cevent)
+	ev := decodeCEvent(cevent)
+	m.state.handleEvent(ev)
+	return ev
+}
+
+// handleEvent passes event to handlers registered internally by the library
+func (s *state) handleEvent(ev *Event) {
+	s.deliverInitial()
+
+	switch ev.EventID {
+	case EventCommandReply, EventGetPropertyReply, EventSetPropertyReply:
+		s.resolveFuture(ev)
+	case EventPropertyChange:
+		s.notifySubscribers(ev)
+	case EventHook:
+		s.runHook(ev)
+	case EventShutdown:
+		s.failFutures(ErrShutdown)
+	}
+
+	s.mu.Lock()
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		s.call(ev, func() { l.fn(ev) })
+	}
+}
+
+// SetPanicHandler sets function called when callback of the handle panics while handling event
+// (subscriber, key binding, message handler, etc.). Panic is recovered and other callbacks
+// are still called, even if there is no panic handler. nil fn removes the handler.
+func (m *Mpv) SetPanicHandler(fn func(ev *Event, recovered interface{})) {
+	m.state.mu.Lock()
+	m.state.panicHandler = fn
+	m.state.mu.Unlock()
+}
+
+// call runs callback for event, recovering its panic like Dispatcher does
+func (s *state) call(ev *Event, fn func()) {
+	s.mu.Lock()
+	handler := s.panicHandler
+	s.mu.Unlock()
+
+	callRecovered(ev, handler, fn)
+}
+
+type listener struct {
+	fn func(ev *Event)
+}
+
+// addListener registers internal function called for every event read from the handle.
+// fn is called from goroutine which reads events, so it must not block.
+func (s *state) addListener(fn func(ev *Event)) (remove func()) {
+	l := &listener{fn: fn}
+
+	s.mu.Lock()
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for i, v := range s.listeners {
+			if v == l {
+				s.listeners = append(s.listeners[:i:i], s.listeners[i+1:]...)
+				return
+			}
+		}
+	}
 }
 
 func decodeCEvent(e *C.mpv_event) *Event {
@@ -161,14 +217,20 @@ func decodeCEvent(e *C.mpv_event) *Event {
 		return &result
 	case EventCommandReply:
 		obj := *(*C.mpv_event_command)(e.data)
-		resp := convert2Data(obj.result, FormatNode)
-		result.Data = ECommandReply(resp.(*Node))
+		result.Data = ECommandReply(decodeCNode(&obj.result))
 		return &result
 	}
 
 	return &result
 }
 
+//export goWakeupCallback
+func goWakeupCallback(handle C.uintptr_t) {
+	if callback, ok := cgo.Handle(handle).Value().(func()); ok {
+		callback()
+	}
+}
+
 func EventName(event EventID) string {
 	return C.GoString(C.mpv_event_name(C.mpv_event_id(event)))
 }
@@ -1,200 +1,321 @@
+//go:build cgo && !nolibmpv
+
 package mpv
 
 // #include "utils.h"
 // #include <stdlib.h>
 import "C"
 import (
-	"encoding/binary"
+	"fmt"
+	"sort"
 	"unsafe"
 )
 
-
-func convert2Pointer(data interface{}, format Format) unsafe.Pointer {
+// convert2Pointer converts data into representation expected by mpv for provided format
+// (e.g. char** for FormatString or mpv_node* for FormatNode).
+//
+// Returned function frees all memory allocated for the conversion and must be called
+// after mpv function returns.
+func convert2Pointer(data interface{}, format Format) (unsafe.Pointer, func(), error) {
 	switch format {
-	case FormatNone:
-		break
 	case FormatString, FormatOsdString:
-		if data == nil {
-			var result *C.char
-			return unsafe.Pointer(&result)
+		str, ok := data.(string)
+		if !ok {
+			return nil, nil, formatMismatch(data, format)
 		}
-		return unsafe.Pointer(C.CString(data.(string)))
+		result := C.CString(str)
+		return unsafe.Pointer(&result), func() { C.free(unsafe.Pointer(result)) }, nil
 	case FormatFlag:
-		if data == nil {
-			var result C.int
-			return unsafe.Pointer(&result)
+		flag, ok := data.(bool)
+		if !ok {
+			return nil, nil, formatMismatch(data, format)
 		}
 		result := C.int(0)
-		if data.(bool) {
+		if flag {
 			result = 1
 		}
-		return unsafe.Pointer(&result)
+		return unsafe.Pointer(&result), func() {}, nil
 	case FormatInt64:
-		if data == nil {
-			var result C.int64_t
-			return unsafe.Pointer(&result)
+		value, inferred, err := inferFormat(data)
+		if err != nil || inferred != FormatInt64 {
+			return nil, nil, formatMismatch(data, format)
 		}
-		result := C.int64_t(data.(int64))
-		return unsafe.Pointer(&result)
+		result := C.int64_t(value.(int64))
+		return unsafe.Pointer(&result), func() {}, nil
 	case FormatDouble:
-		if data == nil {
-			var result C.long
-			return unsafe.Pointer(&result)
+		value, inferred, err := inferFormat(data)
+		if err != nil || (inferred != FormatDouble && inferred != FormatInt64) {
+			return nil, nil, formatMismatch(data, format)
+		}
+		var result C.double
+		if inferred == FormatInt64 {
+			result = C.double(value.(int64))
+		} else {
+			result = C.double(value.(float64))
 		}
-		result := C.long(data.(float64))
+		return unsafe.Pointer(&result), func() {}, nil
+	case FormatNode:
+		node, err := NewNode(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := &C.mpv_node{}
+		free := func() { freeCNodeContents(result) }
+		if err := fillCNode(result, node); err != nil {
+			free()
+			return nil, nil, err
+		}
+		return unsafe.Pointer(result), free, nil
+	case FormatNodeArray, FormatNodeMap, FormatByteArray:
+		return nil, nil, fmt.Errorf("format %d can be used only inside FormatNode", format)
+	}
+	return nil, nil, fmt.Errorf("unsupported format %d", format)
+}
+
+// allocResult returns pointer to zeroed memory where mpv can write value of provided format
+func allocResult(format Format) unsafe.Pointer {
+	switch format {
+	case FormatString, FormatOsdString:
+		var result *C.char
+		return unsafe.Pointer(&result)
+	case FormatFlag:
+		var result C.int
+		return unsafe.Pointer(&result)
+	case FormatInt64:
+		var result C.int64_t
+		return unsafe.Pointer(&result)
+	case FormatDouble:
+		var result C.double
 		return unsafe.Pointer(&result)
 	case FormatNode:
-		if data == nil {
-			var result C.mpv_node
-			return unsafe.Pointer(&result)
+		var result C.mpv_node
+		return unsafe.Pointer(&result)
+	}
+	return nil
+}
+
+// fillCNode recursively encodes node into dst using C memory.
+//
+// dst must be zeroed. On error dst can be partially filled and must still be freed with freeCNodeContents.
+func fillCNode(dst *C.mpv_node, node *Node) error {
+	switch node.Format {
+	case FormatNone:
+		dst.format = C.MPV_FORMAT_NONE
+	case FormatString, FormatOsdString:
+		str, ok := node.Data.(string)
+		if !ok {
+			return formatMismatch(node.Data, node.Format)
+		}
+		*(**C.char)(unsafe.Pointer(&dst.u)) = C.CString(str)
+		dst.format = C.MPV_FORMAT_STRING
+	case FormatFlag:
+		flag, ok := node.Data.(bool)
+		if !ok {
+			return formatMismatch(node.Data, node.Format)
+		}
+		*(*C.int)(unsafe.Pointer(&dst.u)) = 0
+		if flag {
+			*(*C.int)(unsafe.Pointer(&dst.u)) = 1
+		}
+		dst.format = C.MPV_FORMAT_FLAG
+	case FormatInt64:
+		value, inferred, err := inferFormat(node.Data)
+		if err != nil || inferred != FormatInt64 {
+			return formatMismatch(node.Data, node.Format)
+		}
+		*(*C.int64_t)(unsafe.Pointer(&dst.u)) = C.int64_t(value.(int64))
+		dst.format = C.MPV_FORMAT_INT64
+	case FormatDouble:
+		value, inferred, err := inferFormat(node.Data)
+		if err != nil || (inferred != FormatDouble && inferred != FormatInt64) {
+			return formatMismatch(node.Data, node.Format)
+		}
+		if inferred == FormatInt64 {
+			*(*C.double)(unsafe.Pointer(&dst.u)) = C.double(value.(int64))
+		} else {
+			*(*C.double)(unsafe.Pointer(&dst.u)) = C.double(value.(float64))
+		}
+		dst.format = C.MPV_FORMAT_DOUBLE
+	case FormatNode:
+		// Node inside of node does not exist in mpv, so it is flattened
+		inner, err := NewNode(node.Data)
+		if err != nil {
+			return err
+		}
+		if inner.Format == FormatNode {
+			return fmt.Errorf("node with FormatNode must contain another value")
 		}
-		// TODO
+		return fillCNode(dst, inner)
 	case FormatNodeArray:
-		if data == nil {
-			var result C.mpv_node_list
-			return unsafe.Pointer(&result)
+		var values NodeList
+		switch v := node.Data.(type) {
+		case NodeList:
+			values = v
+		case []Node:
+			values = v
+		default:
+			return formatMismatch(node.Data, node.Format)
 		}
 
-		result := C.mpv_node_list{}
-		arr := data.(NodeList)
-
-		result.num = C.int(len(arr))
-		result.values = C.makeNodeList(result.num)
-		result.keys = nil
+		list := newCNodeList(len(values), false)
+		*(**C.mpv_node_list)(unsafe.Pointer(&dst.u)) = list
+		dst.format = C.MPV_FORMAT_NODE_ARRAY
 
-		for i, v := range arr {
-			if v.Data != nil {
-				C.setNodeListElement(result.values, C.int(i), *v.CNode())
+		cvalues := unsafe.Slice(list.values, len(values))
+		for i := range values {
+			if err := fillCNode(&cvalues[i], &values[i]); err != nil {
+				return err
 			}
 		}
-
-		return unsafe.Pointer(&result)
 	case FormatNodeMap:
-		if data == nil {
-			var result C.mpv_node_list
-			return unsafe.Pointer(&result)
+		var values NodeMap
+		switch v := node.Data.(type) {
+		case NodeMap:
+			values = v
+		case map[string]Node:
+			values = v
+		default:
+			return formatMismatch(node.Data, node.Format)
 		}
 
-		result := C.mpv_node_list{}
-		arr := data.(NodeMap)
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 
-		result.num = C.int(len(arr))
-		result.values = C.makeNodeList(result.num)
-		result.keys = C.makeStringArray(result.num)
+		list := newCNodeList(len(values), true)
+		*(**C.mpv_node_list)(unsafe.Pointer(&dst.u)) = list
+		dst.format = C.MPV_FORMAT_NODE_MAP
 
-		index := 0
-		for k, v := range arr {
-			C.setString(result.keys, C.int(index), C.CString(k))
-			if v.Data != nil {
-				C.setNodeListElement(result.values, C.int(index), *v.CNode())
+		ckeys := unsafe.Slice(list.keys, len(values))
+		cvalues := unsafe.Slice(list.values, len(values))
+		for i, k := range keys {
+			ckeys[i] = C.CString(k)
+			value := values[k]
+			if err := fillCNode(&cvalues[i], &value); err != nil {
+				return err
 			}
-			index += 1
 		}
-
-		return unsafe.Pointer(&result)
 	case FormatByteArray:
-		if data == nil {
-			var result C.mpv_byte_array
-			return unsafe.Pointer(&result)
+		data, ok := node.Data.([]byte)
+		if !ok {
+			return formatMismatch(node.Data, node.Format)
 		}
+
+		ba := (*C.mpv_byte_array)(C.calloc(1, C.size_t(unsafe.Sizeof(C.mpv_byte_array{}))))
+		ba.data = C.CBytes(data)
+		ba.size = C.size_t(len(data))
+		*(**C.mpv_byte_array)(unsafe.Pointer(&dst.u)) = ba
+		dst.format = C.MPV_FORMAT_BYTE_ARRAY
+	default:
+		return fmt.Errorf("unsupported node format %d", node.Format)
 	}
 	return nil
 }
 
-func convert2Data(data interface{}, format Format) interface{} {
-	switch format {
-	case FormatNone:
-		return nil
+func newCNodeList(length int, withKeys bool) *C.mpv_node_list {
+	list := (*C.mpv_node_list)(C.calloc(1, C.size_t(unsafe.Sizeof(C.mpv_node_list{}))))
+	list.num = C.int(length)
+	// calloc(0) could return NULL, which is still valid for empty lists
+	list.values = (*C.mpv_node)(C.calloc(C.size_t(length)+1, C.size_t(unsafe.Sizeof(C.mpv_node{}))))
+	if withKeys {
+		list.keys = (**C.char)(C.calloc(C.size_t(length)+1, C.size_t(unsafe.Sizeof((*C.char)(nil)))))
+	}
+	return list
+}
+
+// freeCNodeContents frees memory allocated by fillCNode.
+// It must not be used with nodes returned by mpv (use mpv_free_node_contents instead).
+func freeCNodeContents(node *C.mpv_node) {
+	switch Format(node.format) {
 	case FormatString:
-		if val, ok := data.(*C.char); ok {
-			return C.GoString(val)
-		} else
-		if val, ok := data.(unsafe.Pointer); ok {
-			return C.GoString((*C.char)(val))
-		}
-		val := binary.LittleEndian.Uint64(data.([]byte))
-		return C.GoString((*C.char)(unsafe.Pointer(uintptr(val))))
-	case FormatFlag:
-		if val, ok := data.(C.int); ok {
-			return val == 1
-		} else
-		if val, ok := data.(unsafe.Pointer); ok {
-			return *(*C.int)(val) == 1
-		}
-		return data.(C.int) == 1
-	case FormatInt64:
-		if val, ok := data.(C.int64_t); ok {
-			return int64(val)
-		} else
-		if val, ok := data.(unsafe.Pointer); ok { // Idk if this could even be unsafe.Pointer in real usage.
-			return int64(*(*C.long)(val))
+		C.free(unsafe.Pointer(*(**C.char)(unsafe.Pointer(&node.u))))
+	case FormatNodeArray, FormatNodeMap:
+		list := *(**C.mpv_node_list)(unsafe.Pointer(&node.u))
+		values := unsafe.Slice(list.values, int(list.num))
+		for i := range values {
+			freeCNodeContents(&values[i])
 		}
-		return int64(binary.LittleEndian.Uint64(data.([]byte)))
-	case FormatDouble:
-		if val, ok := data.(C.double); ok {
-			return float64(val)
-		} else
-		if val, ok := data.(unsafe.Pointer); ok {
-			return float64(*(*C.double)(val))
+		if list.keys != nil {
+			for _, k := range unsafe.Slice(list.keys, int(list.num)) {
+				C.free(unsafe.Pointer(k))
+			}
+			C.free(unsafe.Pointer(list.keys))
 		}
+		C.free(unsafe.Pointer(list.values))
+		C.free(unsafe.Pointer(list))
+	case FormatByteArray:
+		ba := *(**C.mpv_byte_array)(unsafe.Pointer(&node.u))
+		C.free(ba.data)
+		C.free(unsafe.Pointer(ba))
+	}
+	*node = C.mpv_node{}
+}
+
+// convert2Data converts value of provided format pointed by data into Go value.
+// data points to the same types as described for convert2Pointer.
+func convert2Data(data unsafe.Pointer, format Format) interface{} {
+	if data == nil {
 		return nil
+	}
+
+	switch format {
+	case FormatString, FormatOsdString:
+		return C.GoString(*(**C.char)(data))
+	case FormatFlag:
+		return *(*C.int)(data) != 0
+	case FormatInt64:
+		return int64(*(*C.int64_t)(data))
+	case FormatDouble:
+		return float64(*(*C.double)(data))
 	case FormatNode:
-		if val, ok := data.(C.mpv_node); ok {
-			content := convert2Data(val.u[:], Format(val.format))
-			return &Node{Data: content, Format: Format(val.format)}
-		} else if val, ok := data.(*C.mpv_node); ok {
-			content := convert2Data(val.u[:], Format(val.format))
-			return &Node{Data: content, Format: Format(val.format)}
-		} else if val, ok := data.(unsafe.Pointer); ok {
-			cnode := *(*C.mpv_node)(val)
-			content := convert2Data(cnode.u[:], Format(cnode.format))
-			return &Node{Data: content, Format: Format(cnode.format)}
-		}
-		return nil
-	case FormatNodeArray:
-		ptr := binary.LittleEndian.Uint64(data.([]byte))
-		arr := *(*C.mpv_node_list)(unsafe.Pointer(uintptr(ptr)))
+		return decodeCNode((*C.mpv_node)(data))
+	case FormatNodeArray, FormatNodeMap:
+		return decodeCNodeList((*C.mpv_node_list)(data), format)
+	case FormatByteArray:
+		return decodeCByteArray((*C.mpv_byte_array)(data))
+	}
+	return nil
+}
 
-		var cvalues *C.mpv_node = arr.values
+func decodeCNode(node *C.mpv_node) *Node {
+	format := Format(node.format)
+	result := &Node{Format: format}
 
-		values := unsafe.Slice(cvalues, int(arr.num))
-		result := make(NodeList, arr.num)
+	switch format {
+	case FormatString, FormatFlag, FormatInt64, FormatDouble:
+		result.Data = convert2Data(unsafe.Pointer(&node.u), format)
+	case FormatNodeArray, FormatNodeMap:
+		result.Data = decodeCNodeList(*(**C.mpv_node_list)(unsafe.Pointer(&node.u)), format)
+	case FormatByteArray:
+		result.Data = decodeCByteArray(*(**C.mpv_byte_array)(unsafe.Pointer(&node.u)))
+	}
+	return result
+}
 
-		for i, v := range values {
-			val := convert2Data(v.u[:], Format(v.format))
+func decodeCNodeList(list *C.mpv_node_list, format Format) interface{} {
+	length := int(list.num)
+	values := unsafe.Slice(list.values, length)
 
-			node := Node{Data: val, Format: Format(v.format)}
-			result[i] = node
+	if format == FormatNodeArray {
+		result := make(NodeList, length)
+		for i := range values {
+			result[i] = *decodeCNode(&values[i])
 		}
 		return result
-	case FormatNodeMap:
-		ptr := binary.LittleEndian.Uint64(data.([]byte))
-		nodeMap := *(*C.mpv_node_list)(unsafe.Pointer(uintptr(ptr)))
-
-		var ckeys **C.char = nodeMap.keys
-		var cvalues *C.mpv_node = nodeMap.values
-
-		keys := unsafe.Slice(ckeys, int(nodeMap.num))
-		values := unsafe.Slice(cvalues, int(nodeMap.num))
-		result := make(NodeMap, nodeMap.num)
-
-		for i, v := range values {
-			val := convert2Data(v.u[:], Format(v.format))
+	}
 
-			node := Node{Data: val, Format: Format(v.format)}
-			key := C.GoString((*C.char)(keys[C.int(i)]))
-			result[key] = node
-		}
-		return result
-	case FormatByteArray:
-		// This is always address to value
-		val := binary.LittleEndian.Uint64(data.([]byte))
-		ptr := unsafe.Pointer(uintptr(val))
-		tmp := *(*C.mpv_byte_array)(ptr)
-		// In libmpv 'size' is int64, but C.GoBytes accept only int
-		// Could be a problem in the future
-		return C.GoBytes(tmp.data, C.int(tmp.size))
+	keys := unsafe.Slice(list.keys, length)
+	result := make(NodeMap, length)
+	for i := range values {
+		result[C.GoString(keys[i])] = *decodeCNode(&values[i])
 	}
+	return result
+}
 
-	return nil
-}
\ No newline at end of file
+func decodeCByteArray(ba *C.mpv_byte_array) []byte {
+	// In libmpv 'size' is size_t, but C.GoBytes accept only int
+	return C.GoBytes(ba.data, C.int(ba.size))
+}
@@ -0,0 +1,175 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"context"
+	"errors"
+)
+
+// firstAutoID is the first ID allocated by the library.
+// IDs chosen by hand (e.g. for CommandAsync) should be lower, so they never collide.
+const firstAutoID uint64 = 1 << 63
+
+// ErrShutdown is returned by futures which were pending when the handle was destroyed or shut down
+var ErrShutdown = errors.New("mpv handle was shut down before request completed")
+
+// Future is result of asynchronous request.
+//
+// It is resolved when reply event with the same ID is read with EventWait
+// (or by the goroutine started with Events), so events must be read for futures to complete.
+type Future struct {
+	id   uint64
+	op   string
+	name string
+	done chan struct{}
+
+	result *Node
+	err    error
+}
+
+// ID returns reply ID of the request
+func (f *Future) ID() uint64 {
+	return f.id
+}
+
+// Done returns channel which is closed when the future is resolved
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits until the future is resolved and returns its result.
+//
+// If ctx is done first, ctx.Err() is returned and request is still pending.
+func (f *Future) Wait(ctx context.Context) (*Node, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *Future) resolve(result *Node, err error) {
+	f.result = result
+	f.err = err
+	close(f.done)
+}
+
+// nextID allocates ID which is unique for this handle
+func (s *state) nextID() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.allocID()
+}
+
+// allocID is like nextID, but must be called with locked mutex
+func (s *state) allocID() uint64 {
+	s.lastID++
+	return firstAutoID + s.lastID
+}
+
+func (m *Mpv) newFuture(op, name string) *Future {
+	f := &Future{id: m.state.nextID(), op: op, name: name, done: make(chan struct{})}
+
+	m.state.mu.Lock()
+	if m.state.futures == nil {
+		m.state.futures = map[uint64]*Future{}
+	}
+	m.state.futures[f.id] = f
+	m.state.mu.Unlock()
+	return f
+}
+
+// takeFuture removes pending future from the handle
+func (s *state) takeFuture(id uint64) *Future {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := s.futures[id]
+	delete(s.futures, id)
+	return f
+}
+
+// failFutures resolves all pending futures with err
+func (s *state) failFutures(err error) {
+	s.mu.Lock()
+	futures := s.futures
+	s.futures = nil
+	s.mu.Unlock()
+
+	for _, f := range futures {
+		f.resolve(nil, err)
+	}
+}
+
+// resolveFuture completes future waiting for reply event
+func (s *state) resolveFuture(ev *Event) {
+	var result *Node
+	switch ev.EventID {
+	case EventCommandReply:
+		result = ev.Data.(ECommandReply)
+	case EventGetPropertyReply:
+		prop := ev.Data.(EProperty)
+		if node, ok := prop.Property.(*Node); ok {
+			result = node
+		} else {
+			result = &Node{Data: prop.Property, Format: prop.Format}
+		}
+	case EventSetPropertyReply:
+	default:
+		return
+	}
+
+	if f := s.takeFuture(ev.ID); f != nil {
+		if ev.Error < 0 {
+			f.resolve(nil, &OpError{Op: f.op, Name: f.name, Code: ev.Error})
+			return
+		}
+		f.resolve(result, nil)
+	}
+}
+
+// CommandFuture is like CommandAsync, but reply ID is allocated automatically
+// and result is delivered through returned future.
+func (m *Mpv) CommandFuture(args []string) (*Future, error) {
+	f := m.newFuture("command_async", CommandName(args))
+	if err := m.CommandAsync(args, f.id); err != nil {
+		m.state.takeFuture(f.id)
+		return nil, err
+	}
+	return f, nil
+}
+
+// CommandNodeFuture is like CommandAsyncNode, but reply ID is allocated automatically
+// and result is delivered through returned future.
+func (m *Mpv) CommandNodeFuture(args *Node) (*Future, error) {
+	f := m.newFuture("command_node_async", CommandNodeName(args))
+	if err := m.CommandAsyncNode(args, f.id); err != nil {
+		m.state.takeFuture(f.id)
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetPropertyFuture is like GetPropertyAsync, but reply ID is allocated automatically.
+// Value of the property is delivered as Node with provided format.
+func (m *Mpv) GetPropertyFuture(name string, format Format) (*Future, error) {
+	f := m.newFuture("get_property_async", name)
+	if err := m.GetPropertyAsync(name, f.id, format); err != nil {
+		m.state.takeFuture(f.id)
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetPropertyFuture is like SetPropertyValueAsync, but reply ID is allocated automatically.
+// Result of returned future is always nil.
+func (m *Mpv) SetPropertyFuture(name string, value interface{}) (*Future, error) {
+	f := m.newFuture("set_property_async", name)
+	if err := m.SetPropertyValueAsync(name, value, f.id); err != nil {
+		m.state.takeFuture(f.id)
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,120 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HookFunc handles hook registered with RegisterHook.
+// ctx is cancelled when the hook times out or the handle is destroyed.
+type HookFunc func(ctx context.Context, h EHook) error
+
+// HookOptions configures hook registered with RegisterHookWithOptions
+type HookOptions struct {
+	// Timeout after which the hook is continued even if handler did not return yet.
+	// Zero means no timeout.
+	Timeout time.Duration
+	// OnError is called when handler returns error, panics or times out.
+	OnError func(h EHook, err error)
+}
+
+// ErrHookTimeout is passed to HookOptions.OnError when handler did not finish in time
+var ErrHookTimeout = errors.New("hook handler timed out")
+
+type hook struct {
+	m    *Mpv
+	fn   HookFunc
+	opts HookOptions
+}
+
+// RegisterHook adds hook (e.g. "on_load") which is handled by fn.
+//
+// fn is called in new goroutine and HookContinue is always called after it returns,
+// even if it returns error or panics. Events must be read (EventWait or Events) for hooks to run.
+// Destroy and Terminate cancel ctx of running handlers and wait until they return,
+// so handlers must not call them.
+// mpv does not allow to remove hooks, they are removed when the handle is destroyed.
+func (m *Mpv) RegisterHook(name string, priority int, fn HookFunc) error {
+	return m.RegisterHookWithOptions(name, priority, fn, HookOptions{})
+}
+
+// RegisterHookWithOptions is like RegisterHook, but allows to set timeout and error handler.
+func (m *Mpv) RegisterHookWithOptions(name string, priority int, fn HookFunc, opts HookOptions) error {
+	h := &hook{m: m, fn: fn, opts: opts}
+
+	m.state.mu.Lock()
+	id := m.state.allocID()
+	if m.state.hooks == nil {
+		m.state.hooks = map[uint64]*hook{}
+	}
+	m.state.hooks[id] = h
+	m.state.mu.Unlock()
+
+	if err := m.HookAdd(name, priority, id); err != nil {
+		m.state.mu.Lock()
+		delete(m.state.hooks, id)
+		m.state.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// runHook starts handler of hook which sent the event
+func (s *state) runHook(ev *Event) {
+	s.mu.Lock()
+	h, ok := s.hooks[ev.ID]
+	s.mu.Unlock()
+
+	if ok {
+		e := ev.Data.(EHook)
+		s.startTask(func(ctx context.Context) { h.run(ctx, e) })
+	}
+}
+
+// run calls the handler and continues the hook, unless the handle was closed meanwhile
+func (h *hook) run(ctx context.Context, e EHook) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := make(chan error, 1)
+	if !h.m.state.startTask(func(context.Context) { result <- h.call(ctx, e) }) {
+		return
+	}
+
+	var timeout <-chan time.Time
+	if h.opts.Timeout > 0 {
+		timer := time.NewTimer(h.opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case err = <-result:
+	case <-timeout:
+		cancel()
+		err = ErrHookTimeout
+	case <-ctx.Done():
+	}
+	if h.m.state.tasksCtx.Err() != nil {
+		return
+	}
+
+	_ = h.m.HookContinue(e.ID)
+	if err != nil && h.opts.OnError != nil {
+		h.opts.OnError(e, err)
+	}
+}
+
+func (h *hook) call(ctx context.Context, e EHook) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("hook handler panicked: %v", r)
+		}
+	}()
+	return h.fn(ctx, e)
+}
@@ -0,0 +1,256 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// KeyPhase is phase of key event, they can be combined when binding keys
+type KeyPhase int
+
+const (
+	KeyDown   KeyPhase = 1 << iota // key was pressed
+	KeyUp                          // key was released
+	KeyRepeat                      // key is held and repeated
+	KeyPress                       // key was pressed and released at once (e.g. mouse wheel or keys without up events)
+
+	// KeyAllPhases matches all phases
+	KeyAllPhases = KeyDown | KeyUp | KeyRepeat | KeyPress
+)
+
+var keyPhases = map[byte]KeyPhase{'d': KeyDown, 'u': KeyUp, 'r': KeyRepeat, 'p': KeyPress}
+
+// KeyEvent is key event delivered to handler bound with InputSection.Bind
+type KeyEvent struct {
+	Key   string // Key is name of the key, as in input.conf (e.g. "Ctrl+a")
+	Text  string // Text is text produced by the key, if any (mpv 0.36+)
+	Phase KeyPhase
+	Mouse bool
+}
+
+// KeyHandler handles key events. It is called from goroutine which reads events, so it should not block.
+type KeyHandler func(KeyEvent)
+
+// ErrSectionClosed is returned when InputSection is used after Close
+var ErrSectionClosed = errors.New("input section is closed")
+
+// InputSection is group of key bindings handled by Go functions.
+//
+// Keys are bound with define-section command to script-binding commands, which mpv delivers
+// to this client as "key-binding" client messages. Events must be read (EventWait or Events)
+// for handlers to run.
+type InputSection struct {
+	m     *Mpv
+	name  string
+	force bool
+
+	mu        sync.Mutex
+	bindings  map[string]*keyBinding // by key
+	byName    map[string]*keyBinding // by name of script-binding
+	lastID    int
+	enabled   bool
+	exclusive bool
+	closed    bool
+	remove    func()
+}
+
+type keyBinding struct {
+	name   string
+	key    string
+	phases KeyPhase
+	fn     KeyHandler
+}
+
+// NewInputSection creates empty section with provided name. Section names are shared by all
+// clients and scripts of the core, so the name should be unique.
+//
+// If force is true, bindings of the section take precedence over user bindings from input.conf.
+// Section is not active until Enable is called.
+func (m *Mpv) NewInputSection(name string, force bool) *InputSection {
+	s := &InputSection{
+		m:        m,
+		name:     name,
+		force:    force,
+		bindings: map[string]*keyBinding{},
+		byName:   map[string]*keyBinding{},
+	}
+	s.remove = m.state.addListener(s.handleEvent)
+
+	m.state.mu.Lock()
+	m.state.sections = append(m.state.sections, s)
+	m.state.mu.Unlock()
+	return s
+}
+
+// Name returns name of the section
+func (s *InputSection) Name() string {
+	return s.name
+}
+
+// Bind binds key to fn, which is called for events with provided phases.
+// Binding the same key again replaces previous binding.
+func (s *InputSection) Bind(key string, phases KeyPhase, fn KeyHandler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSectionClosed
+	}
+	if old, ok := s.bindings[key]; ok {
+		delete(s.byName, old.name)
+	}
+
+	s.lastID++
+	b := &keyBinding{name: s.name + "-" + strconv.Itoa(s.lastID), key: key, phases: phases, fn: fn}
+	s.bindings[key] = b
+	s.byName[b.name] = b
+	return s.define()
+}
+
+// Unbind removes binding of the key
+func (s *InputSection) Unbind(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSectionClosed
+	}
+	b, ok := s.bindings[key]
+	if !ok {
+		return nil
+	}
+	delete(s.bindings, key)
+	delete(s.byName, b.name)
+	return s.define()
+}
+
+// Enable activates the section. If exclusive is true, all other sections (and input.conf)
+// are ignored until this section is disabled.
+func (s *InputSection) Enable(exclusive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSectionClosed
+	}
+
+	flags := "default"
+	if exclusive {
+		flags = "exclusive"
+	}
+	if err := s.m.Command([]string{"enable-section", s.name, flags}); err != nil {
+		return err
+	}
+	s.enabled, s.exclusive = true, exclusive
+	return nil
+}
+
+// Disable deactivates the section
+func (s *InputSection) Disable() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSectionClosed
+	}
+	if err := s.m.Command([]string{"disable-section", s.name}); err != nil {
+		return err
+	}
+	s.enabled = false
+	return nil
+}
+
+// Close disables the section and removes all its bindings. It is safe to call it multiple times.
+// Sections which are still open are closed when the handle is destroyed.
+func (s *InputSection) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.remove()
+
+	state := s.m.state
+	state.mu.Lock()
+	for i, v := range state.sections {
+		if v == s {
+			state.sections = append(state.sections[:i:i], state.sections[i+1:]...)
+			break
+		}
+	}
+	state.mu.Unlock()
+
+	err := s.m.Command([]string{"disable-section", s.name})
+	s.bindings = map[string]*keyBinding{}
+	s.byName = map[string]*keyBinding{}
+	if derr := s.define(); err == nil {
+		err = derr
+	}
+	return err
+}
+
+// define sends current bindings to mpv. It must be called with locked mutex.
+func (s *InputSection) define() error {
+	keys := make([]string, 0, len(s.bindings))
+	for key := range s.bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	target := s.m.ClientName()
+	var contents strings.Builder
+	for _, key := range keys {
+		contents.WriteString(key + " script-binding " + target + "/" + s.bindings[key].name + "\n")
+	}
+
+	flags := "default"
+	if s.force {
+		flags = "force"
+	}
+	return s.m.Command([]string{"define-section", s.name, contents.String(), flags})
+}
+
+// handleEvent runs handler of "key-binding" client message:
+// key-binding <name> <state> <key> [<text>]
+func (s *InputSection) handleEvent(ev *Event) {
+	if ev.EventID != EventClientMessage {
+		return
+	}
+	args, _ := ev.Data.(EClientMessage)
+	if len(args) < 4 || args[0] != "key-binding" || len(args[2]) < 2 {
+		return
+	}
+
+	s.mu.Lock()
+	b, ok := s.byName[args[1]]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	e := KeyEvent{Key: args[3], Phase: keyPhases[args[2][0]], Mouse: args[2][1] == 'm'}
+	if len(args) > 4 {
+		e.Text = args[4]
+	}
+	if b.phases&e.Phase != 0 {
+		b.fn(e)
+	}
+}
+
+// closeSections closes input sections of the handle before it is destroyed
+func (m *Mpv) closeSections() {
+	m.state.mu.Lock()
+	sections := append([]*InputSection(nil), m.state.sections...)
+	m.state.mu.Unlock()
+
+	for _, s := range sections {
+		_ = s.Close()
+	}
+}
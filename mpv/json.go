@@ -0,0 +1,104 @@
+package mpv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MarshalJSON encodes node the same way as mpv JSON IPC does.
+//
+// FormatNone is encoded as null, FormatFlag as bool, FormatNodeArray as array and FormatNodeMap as object.
+// FormatDouble is always encoded with decimal point, so it can be distinguished from FormatInt64.
+// mpv has no JSON representation for FormatByteArray, so it is encoded as base64 string
+// (like []byte in encoding/json) and decoded back as FormatString.
+func (n Node) MarshalJSON() ([]byte, error) {
+	switch n.Format {
+	case FormatNone:
+		return []byte("null"), nil
+	case FormatString, FormatOsdString, FormatFlag, FormatInt64, FormatNodeArray, FormatNodeMap, FormatByteArray, FormatNode:
+		return json.Marshal(n.Data)
+	case FormatDouble:
+		value, ok := n.Data.(float64)
+		if !ok {
+			return nil, formatMismatch(n.Data, n.Format)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("mpv: %v cannot be encoded as JSON", value)
+		}
+
+		result := strconv.AppendFloat(nil, value, 'f', -1, 64)
+		if !bytes.ContainsRune(result, '.') {
+			result = append(result, ".0"...)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("mpv: unsupported node format %d", n.Format)
+}
+
+// UnmarshalJSON decodes node from JSON in format used by mpv JSON IPC.
+//
+// Numbers without fraction and exponent which fit into int64 are decoded as FormatInt64,
+// other numbers as FormatDouble.
+func (n *Node) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	node, err := jsonNode(value)
+	if err != nil {
+		return err
+	}
+	*n = node
+	return nil
+}
+
+func jsonNode(value interface{}) (Node, error) {
+	switch v := value.(type) {
+	case nil:
+		return Node{Format: FormatNone}, nil
+	case bool:
+		return Node{Data: v, Format: FormatFlag}, nil
+	case string:
+		return Node{Data: v, Format: FormatString}, nil
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if i, err := v.Int64(); err == nil {
+				return Node{Data: i, Format: FormatInt64}, nil
+			}
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return Node{}, err
+		}
+		return Node{Data: f, Format: FormatDouble}, nil
+	case []interface{}:
+		list := make(NodeList, len(v))
+		for i, item := range v {
+			node, err := jsonNode(item)
+			if err != nil {
+				return Node{}, err
+			}
+			list[i] = node
+		}
+		return Node{Data: list, Format: FormatNodeArray}, nil
+	case map[string]interface{}:
+		result := make(NodeMap, len(v))
+		for k, item := range v {
+			node, err := jsonNode(item)
+			if err != nil {
+				return Node{}, err
+			}
+			result[k] = node
+		}
+		return Node{Data: result, Format: FormatNodeMap}, nil
+	}
+	return Node{}, fmt.Errorf("mpv: unexpected JSON value %T", value)
+}
@@ -0,0 +1,134 @@
+package mpv
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNodeMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		node Node
+		want string
+	}{
+		{"none", Node{Format: FormatNone}, `null`},
+		{"flag", Node{Data: true, Format: FormatFlag}, `true`},
+		{"int", Node{Data: int64(-3), Format: FormatInt64}, `-3`},
+		{"whole double", Node{Data: 2.0, Format: FormatDouble}, `2.0`},
+		{"double", Node{Data: 0.25, Format: FormatDouble}, `0.25`},
+		{"large double", Node{Data: 1e21, Format: FormatDouble}, `1000000000000000000000.0`},
+		{"string", Node{Data: "a\"b", Format: FormatString}, `"a\"b"`},
+		{"osd string", Node{Data: "a", Format: FormatOsdString}, `"a"`},
+		{"bytes", Node{Data: []byte{1, 2, 3}, Format: FormatByteArray}, `"AQID"`},
+		{"array", Node{Format: FormatNodeArray, Data: NodeList{
+			{Data: "loadfile", Format: FormatString},
+			{Data: 1.0, Format: FormatDouble},
+			{Format: FormatNone},
+		}}, `["loadfile",1.0,null]`},
+		{"map", Node{Format: FormatNodeMap, Data: NodeMap{
+			"b": {Data: int64(1), Format: FormatInt64},
+			"a": {Format: FormatNodeArray, Data: NodeList{{Data: false, Format: FormatFlag}}},
+		}}, `{"a":[false],"b":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeMarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		node Node
+	}{
+		{"NaN", Node{Data: math.NaN(), Format: FormatDouble}},
+		{"infinity", Node{Data: math.Inf(1), Format: FormatDouble}},
+		{"format mismatch", Node{Data: "1", Format: FormatDouble}},
+		{"unknown format", Node{Data: 1, Format: Format(100)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := json.Marshal(tt.node); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestNodeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Node
+	}{
+		{"null", `null`, Node{Format: FormatNone}},
+		{"bool", `false`, Node{Data: false, Format: FormatFlag}},
+		{"int", `42`, Node{Data: int64(42), Format: FormatInt64}},
+		{"negative int", `-7`, Node{Data: int64(-7), Format: FormatInt64}},
+		{"whole double", `42.0`, Node{Data: 42.0, Format: FormatDouble}},
+		{"exponent", `1e3`, Node{Data: 1000.0, Format: FormatDouble}},
+		{"int overflow", `9223372036854775808`, Node{Data: 9223372036854775808.0, Format: FormatDouble}},
+		{"string", `"a"`, Node{Data: "a", Format: FormatString}},
+		{"array", `[1, "a", null]`, Node{Format: FormatNodeArray, Data: NodeList{
+			{Data: int64(1), Format: FormatInt64},
+			{Data: "a", Format: FormatString},
+			{Format: FormatNone},
+		}}},
+		{"map", `{"pos": 1.5, "list": []}`, Node{Format: FormatNodeMap, Data: NodeMap{
+			"pos":  {Data: 1.5, Format: FormatDouble},
+			"list": {Data: NodeList{}, Format: FormatNodeArray},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node Node
+			if err := json.Unmarshal([]byte(tt.data), &node); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(node, tt.want) {
+				t.Errorf("got %#v, want %#v", node, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeJSONRoundTrip(t *testing.T) {
+	node := Node{Format: FormatNodeMap, Data: NodeMap{
+		"name":    {Data: "seek", Format: FormatString},
+		"target":  {Data: 10.0, Format: FormatDouble},
+		"exact":   {Data: true, Format: FormatFlag},
+		"index":   {Data: int64(-1), Format: FormatInt64},
+		"options": {Format: FormatNodeArray, Data: NodeList{{Format: FormatNone}}},
+	}}
+
+	data, err := json.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result Node
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, node) {
+		t.Errorf("got %#v, want %#v", result, node)
+	}
+}
+
+func TestNodeUnmarshalJSONInvalid(t *testing.T) {
+	var node Node
+	if err := node.UnmarshalJSON([]byte(`{"a":`)); err == nil {
+		t.Error("expected error")
+	}
+}
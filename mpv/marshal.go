@@ -0,0 +1,431 @@
+package mpv
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Marshal converts Go value into Node.
+//
+// Structs are converted into NodeMap. Field names can be changed with `mpv` struct tag,
+// for example `mpv:"playlist-pos,omitempty"`. Fields with tag `mpv:"-"` are skipped,
+// fields of embedded structs are treated as fields of outer struct.
+// time.Duration is converted into FormatDouble with number of seconds.
+// Other types are converted as described in NewNode, additionally slices, arrays
+// and maps with string keys of any supported types are allowed.
+func Marshal(v interface{}) (*Node, error) {
+	return marshalValue(reflect.ValueOf(v))
+}
+
+// Unmarshal decodes node into value pointed by v.
+//
+// It is reverse of Marshal. Keys of NodeMap which do not match any struct field are ignored.
+// Struct fields are matched with exact name first, then case-insensitively.
+// FormatNone sets value to its zero value.
+func Unmarshal(node *Node, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("mpv: Unmarshal requires non-nil pointer, got %T", v)
+	}
+	if node == nil {
+		node = &Node{Format: FormatNone}
+	}
+	return unmarshalValue(node, rv.Elem())
+}
+
+var (
+	nodeType     = reflect.TypeOf(Node{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+func marshalValue(rv reflect.Value) (*Node, error) {
+	if !rv.IsValid() {
+		return &Node{Format: FormatNone}, nil
+	}
+
+	switch rv.Type() {
+	case nodeType:
+		node := rv.Interface().(Node)
+		return &node, nil
+	case durationType:
+		return &Node{Data: time.Duration(rv.Int()).Seconds(), Format: FormatDouble}, nil
+	case bytesType:
+		return &Node{Data: append([]byte(nil), rv.Bytes()...), Format: FormatByteArray}, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return &Node{Format: FormatNone}, nil
+		}
+		return marshalValue(rv.Elem())
+	case reflect.Bool:
+		return &Node{Data: rv.Bool(), Format: FormatFlag}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Node{Data: rv.Int(), Format: FormatInt64}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("mpv: value %d overflows int64", rv.Uint())
+		}
+		return &Node{Data: int64(rv.Uint()), Format: FormatInt64}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Node{Data: rv.Float(), Format: FormatDouble}, nil
+	case reflect.String:
+		return &Node{Data: rv.String(), Format: FormatString}, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return &Node{Format: FormatNone}, nil
+		}
+		list := make(NodeList, rv.Len())
+		for i := range list {
+			node, err := marshalValue(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = *node
+		}
+		return &Node{Data: list, Format: FormatNodeArray}, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("mpv: unsupported map key type %s", rv.Type().Key())
+		}
+		if rv.IsNil() {
+			return &Node{Format: FormatNone}, nil
+		}
+		result := make(NodeMap, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			node, err := marshalValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			result[iter.Key().String()] = *node
+		}
+		return &Node{Data: result, Format: FormatNodeMap}, nil
+	case reflect.Struct:
+		result := NodeMap{}
+		for _, f := range structFields(rv.Type()) {
+			field, ok := fieldByIndex(rv, f.index, false)
+			if !ok || (f.omitEmpty && field.IsZero()) {
+				continue
+			}
+			node, err := marshalValue(field)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			result[f.name] = *node
+		}
+		return &Node{Data: result, Format: FormatNodeMap}, nil
+	}
+	return nil, fmt.Errorf("mpv: unsupported type %s", rv.Type())
+}
+
+func unmarshalValue(node *Node, rv reflect.Value) error {
+	if node.Format == FormatNone {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	switch rv.Type() {
+	case nodeType:
+		rv.Set(reflect.ValueOf(*node))
+		return nil
+	case durationType:
+		seconds, ok := nodeFloat(node)
+		if !ok {
+			return unmarshalError(node, rv.Type())
+		}
+		rv.SetInt(int64(seconds * float64(time.Second)))
+		return nil
+	case bytesType:
+		if node.Format != FormatByteArray {
+			break
+		}
+		rv.SetBytes(append([]byte(nil), node.Data.([]byte)...))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return unmarshalValue(node, rv.Elem())
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			break
+		}
+		rv.Set(reflect.ValueOf(nodeInterface(node)))
+		return nil
+	case reflect.Bool:
+		if node.Format != FormatFlag {
+			break
+		}
+		rv.SetBool(node.Data.(bool))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, ok := nodeFloat(node)
+		if !ok || value != math.Trunc(value) || rv.OverflowInt(int64(value)) {
+			break
+		}
+		if node.Format == FormatInt64 {
+			rv.SetInt(node.Data.(int64))
+		} else {
+			rv.SetInt(int64(value))
+		}
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		value, ok := nodeFloat(node)
+		if !ok || value < 0 || value != math.Trunc(value) || rv.OverflowUint(uint64(value)) {
+			break
+		}
+		if node.Format == FormatInt64 {
+			rv.SetUint(uint64(node.Data.(int64)))
+		} else {
+			rv.SetUint(uint64(value))
+		}
+		return nil
+	case reflect.Float32, reflect.Float64:
+		value, ok := nodeFloat(node)
+		if !ok {
+			break
+		}
+		rv.SetFloat(value)
+		return nil
+	case reflect.String:
+		if node.Format != FormatString && node.Format != FormatOsdString {
+			break
+		}
+		rv.SetString(node.Data.(string))
+		return nil
+	case reflect.Slice:
+		list, ok := node.Data.(NodeList)
+		if !ok {
+			break
+		}
+		result := reflect.MakeSlice(rv.Type(), len(list), len(list))
+		for i := range list {
+			if err := unmarshalValue(&list[i], result.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(result)
+		return nil
+	case reflect.Array:
+		list, ok := node.Data.(NodeList)
+		if !ok {
+			break
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i >= len(list) {
+				rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+				continue
+			}
+			if err := unmarshalValue(&list[i], rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		values, ok := node.Data.(NodeMap)
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(values)))
+		}
+		for k, v := range values {
+			v := v
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := unmarshalValue(&v, elem); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Struct:
+		values, ok := node.Data.(NodeMap)
+		if !ok {
+			break
+		}
+		fields := structFields(rv.Type())
+		for k, v := range values {
+			v := v
+			f := findField(fields, k)
+			if f == nil {
+				continue
+			}
+			field, ok := fieldByIndex(rv, f.index, true)
+			if !ok {
+				return fmt.Errorf("mpv: cannot set field %s through nil pointer to unexported embedded struct", f.name)
+			}
+			if err := unmarshalValue(&v, field); err != nil {
+				return fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+		return nil
+	}
+	return unmarshalError(node, rv.Type())
+}
+
+func unmarshalError(node *Node, t reflect.Type) error {
+	return fmt.Errorf("mpv: cannot unmarshal node with format %d into %s", node.Format, t)
+}
+
+// nodeFloat returns numeric value of FormatInt64 and FormatDouble nodes
+func nodeFloat(node *Node) (float64, bool) {
+	switch node.Format {
+	case FormatInt64:
+		return float64(node.Data.(int64)), true
+	case FormatDouble:
+		return node.Data.(float64), true
+	}
+	return 0, false
+}
+
+// nodeInterface converts node into basic Go types (reverse of NewNode)
+func nodeInterface(node *Node) interface{} {
+	switch v := node.Data.(type) {
+	case NodeList:
+		result := make([]interface{}, len(v))
+		for i := range v {
+			result[i] = nodeInterface(&v[i])
+		}
+		return result
+	case NodeMap:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			item := item
+			result[k] = nodeInterface(&item)
+		}
+		return result
+	}
+	return node.Data
+}
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns fields of the struct including fields of embedded structs.
+// Fields of outer struct shadow fields of embedded structs with the same name.
+func structFields(t reflect.Type) []structField {
+	var result []structField
+	seen := map[string]bool{}
+	// visited stops the search at embedded types already expanded at a shallower depth,
+	// which also ends it for types embedding themselves, like encoding/json does
+	visited := map[reflect.Type]bool{}
+
+	current := []structField{{index: nil}}
+	for len(current) > 0 {
+		var next []structField
+		var level []structField
+
+		for _, parent := range current {
+			st := t
+			if len(parent.index) > 0 {
+				st = t.FieldByIndex(parent.index).Type
+				if st.Kind() == reflect.Ptr {
+					st = st.Elem()
+				}
+			}
+			if visited[st] {
+				continue
+			}
+			visited[st] = true
+
+			for i := 0; i < st.NumField(); i++ {
+				sf := st.Field(i)
+				tag := sf.Tag.Get("mpv")
+				if tag == "-" {
+					continue
+				}
+
+				index := append(append([]int(nil), parent.index...), i)
+				name, opts := parseTag(tag)
+
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, structField{index: index})
+					continue
+				}
+				if sf.PkgPath != "" {
+					continue
+				}
+
+				if name == "" {
+					name = sf.Name
+				}
+				level = append(level, structField{name: name, index: index, omitEmpty: hasOption(opts, "omitempty")})
+			}
+		}
+
+		for _, f := range level {
+			if !seen[f.name] {
+				seen[f.name] = true
+				result = append(result, f)
+			}
+		}
+		current = next
+	}
+	return result
+}
+
+func parseTag(tag string) (name, opts string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var current string
+		current, opts = parseTag(opts)
+		if current == option {
+			return true
+		}
+	}
+	return false
+}
+
+func findField(fields []structField, name string) *structField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, name) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but handles nil embedded pointers.
+// If alloc is true, nil pointers are allocated, otherwise (or if pointer cannot be set) ok is false.
+func fieldByIndex(rv reflect.Value, index []int, alloc bool) (field reflect.Value, ok bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !alloc || !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
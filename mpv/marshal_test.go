@@ -0,0 +1,270 @@
+package mpv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	ID   int64  `mpv:"id"`
+	Name string `mpv:"name"`
+}
+
+// ExtraInfo is exported, so nil embedded pointer to it can be allocated by Unmarshal
+type ExtraInfo struct {
+	Extra bool `mpv:"extra"`
+}
+
+type testHidden struct {
+	Hidden bool `mpv:"hidden"`
+}
+
+type testItem struct {
+	testBase
+	*ExtraInfo
+	Name     string        `mpv:"title"` // tag differs, so testBase.Name is not shadowed
+	Position time.Duration `mpv:"time-pos"`
+	Volume   float64       `mpv:"volume,omitempty"`
+	Tags     []string      `mpv:"tags,omitempty"`
+	Skipped  string        `mpv:"-"`
+	Plain    int
+	hidden   int
+}
+
+// testRecursive embeds itself, fields of the embedded value are shadowed by its own
+type testRecursive struct {
+	Value int `mpv:"value"`
+	*testRecursive
+}
+
+func TestMarshal(t *testing.T) {
+	v := testItem{
+		testBase: testBase{ID: 3, Name: "base"},
+		Name:     "title",
+		Position: 1500 * time.Millisecond,
+		Skipped:  "skipped",
+		Plain:    7,
+		hidden:   1,
+	}
+
+	node, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Node{Format: FormatNodeMap, Data: NodeMap{
+		"id":       {Data: int64(3), Format: FormatInt64},
+		"name":     {Data: "base", Format: FormatString},
+		"title":    {Data: "title", Format: FormatString},
+		"time-pos": {Data: 1.5, Format: FormatDouble},
+		"Plain":    {Data: int64(7), Format: FormatInt64},
+	}}
+	if !reflect.DeepEqual(node, want) {
+		t.Errorf("Marshal() = %#v, want %#v", node, want)
+	}
+}
+
+func TestMarshalRecursive(t *testing.T) {
+	v := testRecursive{Value: 1, testRecursive: &testRecursive{Value: 2}}
+
+	node, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Node{Format: FormatNodeMap, Data: NodeMap{"value": {Data: int64(1), Format: FormatInt64}}}
+	if !reflect.DeepEqual(node, want) {
+		t.Errorf("Marshal() = %#v, want %#v", node, want)
+	}
+
+	var result testRecursive
+	if err := Unmarshal(node, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != 1 || result.testRecursive != nil {
+		t.Errorf("Unmarshal() = %+v, want value 1 without embedded value", result)
+	}
+}
+
+func TestMarshalValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  *Node
+	}{
+		{"nil", nil, &Node{Format: FormatNone}},
+		{"nil pointer", (*int)(nil), &Node{Format: FormatNone}},
+		{"bool", true, &Node{Data: true, Format: FormatFlag}},
+		{"int", 5, &Node{Data: int64(5), Format: FormatInt64}},
+		{"uint", uint8(5), &Node{Data: int64(5), Format: FormatInt64}},
+		{"float", float32(0.5), &Node{Data: 0.5, Format: FormatDouble}},
+		{"duration", -2 * time.Second, &Node{Data: -2.0, Format: FormatDouble}},
+		{"bytes", []byte{1, 2}, &Node{Data: []byte{1, 2}, Format: FormatByteArray}},
+		{"nil slice", []string(nil), &Node{Format: FormatNone}},
+		{"array", [2]int{1, 2}, &Node{Format: FormatNodeArray, Data: NodeList{
+			{Data: int64(1), Format: FormatInt64},
+			{Data: int64(2), Format: FormatInt64},
+		}}},
+		{"map", map[string]string{"a": "b"}, &Node{Format: FormatNodeMap, Data: NodeMap{
+			"a": {Data: "b", Format: FormatString},
+		}}},
+		{"node", Node{Data: "a", Format: FormatOsdString}, &Node{Data: "a", Format: FormatOsdString}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(node, tt.want) {
+				t.Errorf("Marshal() = %#v, want %#v", node, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"uint overflow", uint64(1 << 63)},
+		{"map key", map[int]string{1: "a"}},
+		{"channel", make(chan int)},
+		{"struct field", struct{ C chan int }{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Marshal(tt.value); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	node := &Node{Format: FormatNodeMap, Data: NodeMap{
+		"id":       {Data: int64(3), Format: FormatInt64},
+		"name":     {Data: "base", Format: FormatString},
+		"extra":    {Data: true, Format: FormatFlag},
+		"title":    {Data: "title", Format: FormatString},
+		"time-pos": {Data: int64(2), Format: FormatInt64},
+		"volume":   {Data: int64(50), Format: FormatInt64},
+		"tags": {Format: FormatNodeArray, Data: NodeList{
+			{Data: "a", Format: FormatString},
+			{Data: "b", Format: FormatString},
+		}},
+		"plain":   {Data: 7.0, Format: FormatDouble}, // matched case-insensitively
+		"-":       {Data: "skipped", Format: FormatString},
+		"unknown": {Data: "ignored", Format: FormatString},
+	}}
+
+	var v testItem
+	if err := Unmarshal(node, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	want := testItem{
+		testBase:  testBase{ID: 3, Name: "base"},
+		ExtraInfo: &ExtraInfo{Extra: true},
+		Name:      "title",
+		Position:  2 * time.Second,
+		Volume:    50,
+		Tags:      []string{"a", "b"},
+		Plain:     7,
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", v, want)
+	}
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	v := testItem{
+		testBase:  testBase{ID: 1, Name: "a"},
+		ExtraInfo: &ExtraInfo{Extra: true},
+		Position:  250 * time.Millisecond,
+		Tags:      []string{"x"},
+	}
+
+	node, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result testItem
+	if err := Unmarshal(node, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, v) {
+		t.Errorf("got %+v, want %+v", result, v)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	var i int
+	if err := Unmarshal(&Node{Data: 4.0, Format: FormatDouble}, &i); err != nil || i != 4 {
+		t.Errorf("int from whole double = %d, %v", i, err)
+	}
+
+	var d time.Duration
+	if err := Unmarshal(&Node{Data: 0.25, Format: FormatDouble}, &d); err != nil || d != 250*time.Millisecond {
+		t.Errorf("duration = %v, %v", d, err)
+	}
+
+	var p *string
+	if err := Unmarshal(&Node{Data: "a", Format: FormatString}, &p); err != nil || p == nil || *p != "a" {
+		t.Errorf("pointer = %v, %v", p, err)
+	}
+
+	s := "a"
+	if err := Unmarshal(&Node{Format: FormatNone}, &s); err != nil || s != "" {
+		t.Errorf("FormatNone = %q, %v", s, err)
+	}
+
+	var m map[string]int
+	if err := Unmarshal(&Node{Format: FormatNodeMap, Data: NodeMap{"a": {Data: int64(1), Format: FormatInt64}}}, &m); err != nil || m["a"] != 1 {
+		t.Errorf("map = %v, %v", m, err)
+	}
+
+	var value interface{}
+	if err := Unmarshal(&Node{Format: FormatNodeArray, Data: NodeList{{Data: int64(1), Format: FormatInt64}}}, &value); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int64(1)}; !reflect.DeepEqual(value, want) {
+		t.Errorf("interface = %#v, want %#v", value, want)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  *Node
+		value interface{}
+	}{
+		{"fraction into int", &Node{Data: 1.5, Format: FormatDouble}, new(int)},
+		{"overflow", &Node{Data: int64(300), Format: FormatInt64}, new(int8)},
+		{"negative uint", &Node{Data: int64(-1), Format: FormatInt64}, new(uint)},
+		{"string into bool", &Node{Data: "yes", Format: FormatString}, new(bool)},
+		{"list into struct", &Node{Data: NodeList{}, Format: FormatNodeArray}, new(testBase)},
+		{"struct field", &Node{Format: FormatNodeMap, Data: NodeMap{"id": {Data: "a", Format: FormatString}}}, new(testBase)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Unmarshal(tt.node, tt.value); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	var hidden struct{ *testHidden }
+	if err := Unmarshal(&Node{Format: FormatNodeMap, Data: NodeMap{"hidden": {Data: true, Format: FormatFlag}}}, &hidden); err == nil {
+		t.Error("expected error for nil pointer to unexported embedded struct")
+	}
+
+	var v testBase
+	if err := Unmarshal(&Node{Format: FormatNone}, v); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
@@ -1,16 +1,63 @@
+//go:build cgo && !nolibmpv
+
 package mpv
 
+// #cgo LDFLAGS: -lmpv
 // #include "utils.h"
 // #include <stdlib.h>
 import "C"
 import (
+	"context"
 	"errors"
 	"fmt"
+	"runtime/cgo"
+	"sync"
 	"unsafe"
 )
 
+var (
+	_ Player     = (*Mpv)(nil)
+	_ Subscriber = (*Mpv)(nil)
+)
+
 type Mpv struct {
-	ctx *C.mpv_handle
+	ctx   *C.mpv_handle
+	state *state
+}
+
+// state holds Go side data bound to a single client handle.
+// It is shared between copies of Mpv value.
+type state struct {
+	mu     sync.Mutex
+	wakeup cgo.Handle
+	events *eventLoop
+
+	lastID    uint64
+	futures   map[uint64]*Future
+	listeners []*listener
+
+	observations   map[observationKey]*observation
+	observationIDs map[uint64]*observation
+	initial        []initialValue
+	hooks          map[uint64]*hook
+
+	overlays       [maxOverlays]bool
+	lastOsdOverlay int64
+	sections       []*InputSection
+	rpc            *rpcState
+	panicHandler   func(ev *Event, recovered interface{})
+
+	// tasks are goroutines started for events (hook handlers, ...), which may call the handle.
+	// They are cancelled with tasksCtx and waited for before the handle is destroyed.
+	tasks       sync.WaitGroup
+	tasksCtx    context.Context
+	cancelTasks context.CancelFunc
+	closed      bool
+}
+
+func newMpv(handle *C.mpv_handle) *Mpv {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Mpv{ctx: handle, state: &state{tasksCtx: ctx, cancelTasks: cancel}}
 }
 
 // Create creates new mpv instance and client API handle to control the mpv instance.
@@ -19,7 +66,7 @@ func Create() (*Mpv, error) {
 	if handle == nil {
 		return nil, errors.New("cannot create mpv instance. Possible reasons: *out of memory* or *LC_NUMERIC != \"C\"*")
 	}
-	return &Mpv{ctx: handle}, nil
+	return newMpv(handle), nil
 }
 
 // CreateClient creates a new client handle connected to the same player core as current client.
@@ -31,7 +78,7 @@ func (m *Mpv) CreateClient(name string) (*Mpv, error) {
 	if handle == nil {
 		return nil, errors.New("cannot create mpv client instance")
 	}
-	return &Mpv{ctx: handle}, nil
+	return newMpv(handle), nil
 }
 
 // CreateWeakClient creates weak handle reference.
@@ -45,22 +92,76 @@ func (m *Mpv) CreateWeakClient(name string) (*Mpv, error) {
 	if handle == nil {
 		return nil, errors.New("cannot create mpv client instance")
 	}
-	return &Mpv{ctx: handle}, nil
+	return newMpv(handle), nil
 }
 
 // Initialize initializes uninitialized mpv instance
 func (m *Mpv) Initialize() error {
-	return Error(C.mpv_initialize(m.ctx)).Err()
+	return newOpError("initialize", "", C.mpv_initialize(m.ctx))
 }
 
 // Destroy disconnects and destroys mpv handle
 func (m *Mpv) Destroy() {
+	m.closeSections()
+	m.stopEventLoop()
+	m.state.stopTasks()
 	C.mpv_destroy(m.ctx)
+	m.releaseCallbacks()
+}
+
+// Close destroys the handle like Destroy. It exists to implement Player.
+func (m *Mpv) Close() error {
+	m.Destroy()
+	return nil
 }
 
 // Terminate terminates the player and all clients, and waits until all of them are destroyed
 func (m *Mpv) Terminate() {
+	m.closeSections()
+	m.stopEventLoop()
+	m.state.stopTasks()
 	C.mpv_terminate_destroy(m.ctx)
+	m.releaseCallbacks()
+}
+
+// releaseCallbacks frees Go callbacks registered for destroyed handle
+func (m *Mpv) releaseCallbacks() {
+	m.state.failFutures(ErrShutdown)
+
+	m.state.mu.Lock()
+	defer m.state.mu.Unlock()
+
+	if m.state.wakeup != 0 {
+		m.state.wakeup.Delete()
+		m.state.wakeup = 0
+	}
+}
+
+// startTask runs fn in new goroutine, which is waited for before the handle is destroyed.
+// ctx of fn is cancelled when destroying starts. It returns false if the handle is already closed.
+func (s *state) startTask(fn func(ctx context.Context)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.tasks.Add(1)
+	go func() {
+		defer s.tasks.Done()
+		fn(s.tasksCtx)
+	}()
+	return true
+}
+
+// stopTasks cancels running tasks and waits until they return
+func (s *state) stopTasks() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancelTasks()
+	s.tasks.Wait()
 }
 
 // ClientName returns the name of current client handle
@@ -80,7 +181,7 @@ func (m *Mpv) LoadConfig(filename string) error {
 	cfilename := C.CString(filename)
 	defer C.free(unsafe.Pointer(cfilename))
 
-	return Error(C.mpv_load_config_file(m.ctx, cfilename)).Err()
+	return newOpError("load_config_file", filename, C.mpv_load_config_file(m.ctx, cfilename))
 }
 
 // InternalTime returns internal time in microseconds.
@@ -94,8 +195,14 @@ func (m *Mpv) SetOption(name string, option interface{}, format Format) error {
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
 
-	code := C.mpv_set_option(m.ctx, cname, C.mpv_format(format), convert2Pointer(option, format))
-	return Error(code).Err()
+	ptr, free, err := convert2Pointer(option, format)
+	if err != nil {
+		return &OpError{Op: "set_option", Name: name, Code: ErrOptionFormat, Err: err}
+	}
+	defer free()
+
+	code := C.mpv_set_option(m.ctx, cname, C.mpv_format(format), ptr)
+	return newOpError("set_option", name, code)
 }
 
 func (m *Mpv) SetOptionString(name, option string) error {
@@ -105,15 +212,21 @@ func (m *Mpv) SetOptionString(name, option string) error {
 	defer C.free(unsafe.Pointer(coption))
 
 	code := C.mpv_set_option_string(m.ctx, cname, coption)
-	return Error(code).Err()
+	return newOpError("set_option_string", name, code)
 }
 
 func (m *Mpv) SetProperty(name string, property interface{}, format Format) error {
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
 
-	code := C.mpv_set_property(m.ctx, cname, C.mpv_format(format), convert2Pointer(property, format))
-	return Error(code).Err()
+	ptr, free, err := convert2Pointer(property, format)
+	if err != nil {
+		return &OpError{Op: "set_property", Name: name, Code: ErrPropertyFormat, Err: err}
+	}
+	defer free()
+
+	code := C.mpv_set_property(m.ctx, cname, C.mpv_format(format), ptr)
+	return newOpError("set_property", name, code)
 }
 
 func (m *Mpv) SetPropertyString(name, property string) error {
@@ -123,65 +236,65 @@ func (m *Mpv) SetPropertyString(name, property string) error {
 	defer C.free(unsafe.Pointer(cproperty))
 
 	code := C.mpv_set_property_string(m.ctx, cname, cproperty)
-	return Error(code).Err()
+	return newOpError("set_property_string", name, code)
 }
 
 func (m *Mpv) SetPropertyAsync(name string, property interface{}, id uint64, format Format) error {
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
 
-	code := C.mpv_set_property_async(m.ctx, C.ulong(id), cname, C.mpv_format(format), convert2Pointer(property, format))
-	return Error(code).Err()
+	ptr, free, err := convert2Pointer(property, format)
+	if err != nil {
+		return &OpError{Op: "set_property_async", Name: name, Code: ErrPropertyFormat, Err: err}
+	}
+	defer free()
+
+	code := C.mpv_set_property_async(m.ctx, C.ulong(id), cname, C.mpv_format(format), ptr)
+	return newOpError("set_property_async", name, code)
 }
 
 func (m *Mpv) GetProperty(name string, format Format) (interface{}, error) {
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
 
-	result := convert2Pointer(nil, format)
-
-	switch format {
-	case FormatString:
-		defer C.free(result)
-	case FormatNode:
-		defer C.mpv_free_node_contents((*C.mpv_node)(result))
+	result := allocResult(format)
+	if result == nil {
+		err := fmt.Errorf("format %d is not supported for reading properties", format)
+		return nil, &OpError{Op: "get_property", Name: name, Code: ErrPropertyFormat, Err: err}
 	}
 
 	code := C.mpv_get_property(m.ctx, cname, C.mpv_format(format), result)
-	if code != 0 {
-		return nil, Error(code).Err()
+	if err := newOpError("get_property", name, code); err != nil {
+		return nil, err
 	}
-	if result == nil {
-		return nil, fmt.Errorf("returned value is nil. Probably format type is invalid")
+
+	switch format {
+	case FormatString, FormatOsdString:
+		defer C.mpv_free(unsafe.Pointer(*(**C.char)(result)))
+	case FormatNode:
+		defer C.mpv_free_node_contents((*C.mpv_node)(result))
 	}
 
 	return convert2Data(result, format), nil
 }
 
+// GetPropertyString returns value of property formatted as string
 func (m *Mpv) GetPropertyString(name string) (string, error) {
-	cname := C.CString(name)
-	defer C.free(unsafe.Pointer(cname))
-
-	cresult := C.mpv_get_property_string(m.ctx, cname)
-
-	if cresult == nil {
-		return "", fmt.Errorf("cannot find property with provided name ('%s')", name)
+	// mpv_get_property_string does not report reason of failure, so mpv_get_property is used instead
+	result, err := m.GetProperty(name, FormatString)
+	if err != nil {
+		return "", err
 	}
-	defer C.mpv_free(unsafe.Pointer(cresult))
-	return C.GoString(cresult), nil
+	return result.(string), nil
 }
 
+// GetPropertyOsdString returns value of property formatted for OSD
 func (m *Mpv) GetPropertyOsdString(name string) (string, error) {
-	cname := C.CString(name)
-	defer C.free(unsafe.Pointer(cname))
-
-	cresult := C.mpv_get_property_osd_string(m.ctx, cname)
-
-	if cresult == nil {
-		return "", fmt.Errorf("cannot find property with provided name ('%s')", name)
+	result, err := m.GetProperty(name, FormatOsdString)
+	if err != nil {
+		return "", err
 	}
-	defer C.mpv_free(unsafe.Pointer(cresult))
-	return C.GoString(cresult), nil
+	return result.(string), nil
 }
 
 func (m *Mpv) GetPropertyAsync(name string, id uint64, format Format) error {
@@ -189,88 +302,71 @@ func (m *Mpv) GetPropertyAsync(name string, id uint64, format Format) error {
 	defer C.free(unsafe.Pointer(cname))
 
 	code := C.mpv_get_property_async(m.ctx, C.ulong(id), cname, C.mpv_format(format))
-	return Error(code).Err()
+	return newOpError("get_property_async", name, code)
 }
 
 func (m *Mpv) Command(args []string) error {
-	array := C.makeStringArray(C.int(len(args)))
-
-	for i, v := range args {
-		cvalue := C.CString(v)
-		defer C.free(unsafe.Pointer(cvalue))
+	array, free := newCStringArray(args)
+	defer free()
 
-		C.setString(array, C.int(i), cvalue)
-	}
-
-	return Error(C.mpv_command(m.ctx, array)).Err()
+	return newOpError("command", CommandName(args), C.mpv_command(m.ctx, array))
 }
 
 func (m *Mpv) CommandString(command string) error {
 	ccmd := C.CString(command)
 	defer C.free(unsafe.Pointer(ccmd))
 
-	return Error(C.mpv_command_string(m.ctx, ccmd)).Err()
+	return newOpError("command_string", command, C.mpv_command_string(m.ctx, ccmd))
 }
 
 func (m *Mpv) CommandAsync(args []string, id uint64) error {
-	array := C.makeStringArray(C.int(len(args)))
-
-	for i, v := range args {
-		cvalue := C.CString(v)
-		defer C.free(unsafe.Pointer(cvalue))
-
-		C.setString(array, C.int(i), cvalue)
-	}
+	array, free := newCStringArray(args)
+	defer free()
 
 	code := C.mpv_command_async(m.ctx, C.ulong(id), array)
-	return Error(code).Err()
+	return newOpError("command_async", CommandName(args), code)
 }
 
 func (m *Mpv) CommandNode(args *Node) (*Node, error) {
-	cnode := args.CNode()
-
-	var cresult *C.mpv_node = &C.mpv_node{}
-	if code := C.mpv_command_node(m.ctx, cnode, cresult); code != 0 {
-		return nil, Error(code).Err()
+	cnode, free, err := convert2Pointer(args, FormatNode)
+	if err != nil {
+		return nil, &OpError{Op: "command_node", Name: CommandNodeName(args), Code: ErrInvalidParameter, Err: err}
 	}
+	defer free()
 
-	if cresult != nil {
-		defer C.mpv_free_node_contents(cresult)
-		node := convert2Data(cresult, FormatNode)
-		return node.(*Node), nil
+	var cresult C.mpv_node
+	code := C.mpv_command_node(m.ctx, (*C.mpv_node)(cnode), &cresult)
+	if err := newOpError("command_node", CommandNodeName(args), code); err != nil {
+		return nil, err
 	}
-	return nil, nil
+	defer C.mpv_free_node_contents(&cresult)
+
+	return decodeCNode(&cresult), nil
 }
 
 func (m *Mpv) CommandAsyncNode(args *Node, id uint64) error {
-	cnode := args.CNode()
+	cnode, free, err := convert2Pointer(args, FormatNode)
+	if err != nil {
+		return &OpError{Op: "command_node_async", Name: CommandNodeName(args), Code: ErrInvalidParameter, Err: err}
+	}
+	defer free()
 
-	code := C.mpv_command_node_async(m.ctx, C.ulong(id), cnode)
-	return Error(code).Err()
+	code := C.mpv_command_node_async(m.ctx, C.ulong(id), (*C.mpv_node)(cnode))
+	return newOpError("command_node_async", CommandNodeName(args), code)
 }
 
 func (m *Mpv) CommandReturn(args []string) (*Node, error) {
-	array := C.makeStringArray(C.int(len(args)))
-
-	for i, v := range args {
-		cvalue := C.CString(v)
-		defer C.free(unsafe.Pointer(cvalue))
-
-		C.setString(array, C.int(i), cvalue)
-	}
+	array, free := newCStringArray(args)
+	defer free()
 
-	var cresult *C.mpv_node = &C.mpv_node{}
-	code := C.mpv_command_ret(m.ctx, array, cresult)
-	if code != 0 {
-		return nil, Error(code).Err()
+	var cresult C.mpv_node
+	code := C.mpv_command_ret(m.ctx, array, &cresult)
+	if err := newOpError("command_ret", CommandName(args), code); err != nil {
+		return nil, err
 	}
+	defer C.mpv_free_node_contents(&cresult)
 
-	if cresult != nil {
-		defer C.mpv_free_node_contents(cresult)
-		node := convert2Data(cresult, FormatNode)
-		return node.(*Node), nil
-	}
-	return nil, nil
+	return decodeCNode(&cresult), nil
 }
 
 func (m *Mpv) AbortAsyncCommand(id uint64) {
@@ -282,18 +378,33 @@ func (m *Mpv) ObserveProperty(name string, id uint64, format Format) error {
 	defer C.free(unsafe.Pointer(cname))
 
 	code := C.mpv_observe_property(m.ctx, C.ulong(id), cname, C.mpv_format(format))
-	return Error(code).Err()
+	return newOpError("observe_property", name, code)
 }
 
 func (m *Mpv) UnObserveProperty(id uint64) (int, error) {
 	num := C.mpv_unobserve_property(m.ctx, C.ulong(id))
 	if num < 0 {
-		return 0, Error(num).Err()
+		return 0, newOpError("unobserve_property", "", num)
 	}
 	return int(num), nil
 }
 
+// newCStringArray returns NULL terminated array of C strings and function which frees it
+func newCStringArray(args []string) (**C.char, func()) {
+	array := C.makeStringArray(C.int(len(args) + 1))
+	for i, v := range args {
+		C.setString(array, C.int(i), C.CString(v))
+	}
+
+	return array, func() {
+		for _, v := range unsafe.Slice(array, len(args)) {
+			C.free(unsafe.Pointer(v))
+		}
+		C.free(unsafe.Pointer(array))
+	}
+}
+
 // ClientApiVersion returns version of compiled mpv
 func ClientApiVersion() uint64 {
 	return uint64(C.mpv_client_api_version())
-}
\ No newline at end of file
+}
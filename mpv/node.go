@@ -1,45 +1,121 @@
 package mpv
-import "C"
 
-// #cgo LDFLAGS: -lmpv
-// #include <mpv/client.h>
-// #include <stdlib.h>
-/*
-
-//mpv_node* makeNodeList(int length) {
-//	return calloc(sizeof(mpv_node), length);
-//}
-//
-//void setNodeListElement(mpv_node* values, int index, mpv_node value) {
-//	values[index] = value;
-//}
-
- */
-import "C"
 import (
-	"encoding/binary"
+	"fmt"
+	"math"
 )
 
+// Node represents mpv_node. Data type depends on Format:
+// string for FormatString, bool for FormatFlag, int64 for FormatInt64, float64 for FormatDouble,
+// NodeList for FormatNodeArray, NodeMap for FormatNodeMap, []byte for FormatByteArray and nil for FormatNone.
 type Node struct {
 	Data interface{}
 	Format Format
 }
 
+type NodeList []Node
+type NodeMap map[string]Node
 
-func (n *Node) CNode() *C.mpv_node {
-	ptr := convert2Pointer(n.Data, n.Format)
-	if ptr == nil {
-		return nil
+// NewNode converts Go value into Node.
+//
+// Supported types are bool, all int and uint kinds, float32, float64, string, []byte,
+// Node, *Node, NodeList, NodeMap, []interface{} and map[string]interface{}
+// (values of the last two are converted recursively). nil is converted into FormatNone node.
+func NewNode(value interface{}) (*Node, error) {
+	switch v := value.(type) {
+	case nil:
+		return &Node{Data: nil, Format: FormatNone}, nil
+	case Node:
+		return &v, nil
+	case *Node:
+		if v == nil {
+			return &Node{Data: nil, Format: FormatNone}, nil
+		}
+		return v, nil
+	case NodeList:
+		return &Node{Data: v, Format: FormatNodeArray}, nil
+	case NodeMap:
+		return &Node{Data: v, Format: FormatNodeMap}, nil
+	case []byte:
+		return &Node{Data: v, Format: FormatByteArray}, nil
+	case []interface{}:
+		list := make(NodeList, len(v))
+		for i, item := range v {
+			node, err := NewNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = *node
+		}
+		return &Node{Data: list, Format: FormatNodeArray}, nil
+	case map[string]interface{}:
+		nodeMap := make(NodeMap, len(v))
+		for key, item := range v {
+			node, err := NewNode(item)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", key, err)
+			}
+			nodeMap[key] = *node
+		}
+		return &Node{Data: nodeMap, Format: FormatNodeMap}, nil
 	}
 
-	var buf [8]byte
-	binary.LittleEndian.PutUint64(buf[:], uint64(uintptr(ptr)))
+	data, format, err := inferFormat(value)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Data: data, Format: format}, nil
+}
 
-	result := &C.mpv_node{}
-	result.u = buf
-	result.format = C.mpv_format(n.Format)
-	return result
+// inferFormat returns value converted into type expected by convert2Pointer and its format.
+// Values which cannot be represented as basic formats are converted into *Node with FormatNode.
+func inferFormat(value interface{}) (interface{}, Format, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, FormatFlag, nil
+	case int:
+		return int64(v), FormatInt64, nil
+	case int8:
+		return int64(v), FormatInt64, nil
+	case int16:
+		return int64(v), FormatInt64, nil
+	case int32:
+		return int64(v), FormatInt64, nil
+	case int64:
+		return v, FormatInt64, nil
+	case uint:
+		return inferUint(uint64(v))
+	case uint8:
+		return int64(v), FormatInt64, nil
+	case uint16:
+		return int64(v), FormatInt64, nil
+	case uint32:
+		return int64(v), FormatInt64, nil
+	case uint64:
+		return inferUint(v)
+	case float32:
+		return float64(v), FormatDouble, nil
+	case float64:
+		return v, FormatDouble, nil
+	case string:
+		return v, FormatString, nil
+	case nil, Node, *Node, NodeList, NodeMap, []byte, []interface{}, map[string]interface{}:
+		node, err := NewNode(v)
+		if err != nil {
+			return nil, FormatNone, err
+		}
+		return node, FormatNode, nil
+	}
+	return nil, FormatNone, fmt.Errorf("cannot infer mpv format of %T", value)
 }
 
-type NodeList []Node
-type NodeMap map[string]Node
+func inferUint(v uint64) (interface{}, Format, error) {
+	if v > math.MaxInt64 {
+		return nil, FormatNone, fmt.Errorf("value %d overflows int64", v)
+	}
+	return int64(v), FormatInt64, nil
+}
+
+func formatMismatch(data interface{}, format Format) error {
+	return fmt.Errorf("value of type %T cannot be used with format %d", data, format)
+}
@@ -0,0 +1,213 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"sync"
+)
+
+// maxOverlays is number of overlay IDs supported by overlay-add command
+const maxOverlays = 64
+
+// ErrNoOverlays is returned when all overlay IDs of overlay-add are in use
+var ErrNoOverlays = errors.New("all overlay IDs are in use")
+
+// ImageOverlay is image shown on top of the video with overlay-add command
+type ImageOverlay struct {
+	m  *Mpv
+	id int
+
+	mu      sync.Mutex
+	removed bool
+}
+
+// AddImageOverlay shows img with its top-left corner at (x, y) in window coordinates.
+//
+// IDs of overlays (0-63) are allocated automatically. Note that mpv shares them between
+// all clients of the core, so they can collide with overlays added by scripts.
+func (m *Mpv) AddImageOverlay(img image.Image, x, y int) (*ImageOverlay, error) {
+	m.state.mu.Lock()
+	id := -1
+	for i, used := range m.state.overlays {
+		if !used {
+			id = i
+			m.state.overlays[i] = true
+			break
+		}
+	}
+	m.state.mu.Unlock()
+
+	if id < 0 {
+		return nil, &OpError{Op: "command", Name: "overlay-add", Code: ErrGeneric, Err: ErrNoOverlays}
+	}
+
+	o := &ImageOverlay{m: m, id: id}
+	if err := o.show(img, x, y); err != nil {
+		o.release()
+		return nil, err
+	}
+	return o, nil
+}
+
+// ID returns ID of the overlay used in overlay-add command
+func (o *ImageOverlay) ID() int {
+	return o.id
+}
+
+// Update replaces image and position of the overlay
+func (o *ImageOverlay) Update(img image.Image, x, y int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.removed {
+		return &OpError{Op: "command", Name: "overlay-add", Code: ErrInvalidParameter, Err: errors.New("overlay was removed")}
+	}
+	return o.show(img, x, y)
+}
+
+// Remove hides the overlay and releases its ID. It is safe to call it multiple times.
+func (o *ImageOverlay) Remove() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.removed {
+		return nil
+	}
+	o.removed = true
+	err := o.m.Command([]string{"overlay-remove", strconv.Itoa(o.id)})
+	o.release()
+	return err
+}
+
+func (o *ImageOverlay) release() {
+	o.m.state.mu.Lock()
+	o.m.state.overlays[o.id] = false
+	o.m.state.mu.Unlock()
+}
+
+// show passes image to mpv as premultiplied BGRA in C memory.
+// mpv copies the bitmap while running the command, so the memory is freed right after it.
+func (o *ImageOverlay) show(img image.Image, x, y int) error {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return &OpError{Op: "command", Name: "overlay-add", Code: ErrInvalidParameter, Err: errors.New("image is empty")}
+	}
+
+	// image.RGBA is premultiplied, only red and blue have to be swapped
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		rgba.Pix[i], rgba.Pix[i+2] = rgba.Pix[i+2], rgba.Pix[i]
+	}
+
+	data := C.CBytes(rgba.Pix)
+	defer C.free(data)
+
+	return o.m.Command([]string{
+		"overlay-add",
+		strconv.Itoa(o.id),
+		strconv.Itoa(x),
+		strconv.Itoa(y),
+		fmt.Sprintf("&%d", uintptr(data)),
+		"0",
+		"bgra",
+		strconv.Itoa(rgba.Rect.Dx()),
+		strconv.Itoa(rgba.Rect.Dy()),
+		strconv.Itoa(rgba.Stride),
+	})
+}
+
+// ASSOverlayOptions configures overlay added with AddASSOverlay
+type ASSOverlayOptions struct {
+	// ResX and ResY are resolution of coordinate space used in ASS events.
+	// If ResY is 0, 720 is used and if ResX is 0, it is computed from ResY and aspect ratio of the window.
+	ResX, ResY int
+	Z          int // Z is order of overlays, higher values are drawn on top
+	Hidden     bool
+}
+
+// ASSOverlay is OSD layer with ASS events, shown with osd-overlay command
+type ASSOverlay struct {
+	m    *Mpv
+	id   int64
+	opts ASSOverlayOptions
+
+	mu      sync.Mutex
+	removed bool
+}
+
+// AddASSOverlay shows OSD layer with provided ASS events (e.g. "{\\an7}text").
+// IDs of these overlays are unique per client handle.
+func (m *Mpv) AddASSOverlay(data string, opts ASSOverlayOptions) (*ASSOverlay, error) {
+	m.state.mu.Lock()
+	m.state.lastOsdOverlay++
+	id := m.state.lastOsdOverlay
+	m.state.mu.Unlock()
+
+	o := &ASSOverlay{m: m, id: id, opts: opts}
+	if err := o.set("ass-events", data); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ID returns ID of the overlay used in osd-overlay command
+func (o *ASSOverlay) ID() int64 {
+	return o.id
+}
+
+// Update replaces ASS events of the overlay
+func (o *ASSOverlay) Update(data string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.removed {
+		return &OpError{Op: "command", Name: "osd-overlay", Code: ErrInvalidParameter, Err: errors.New("overlay was removed")}
+	}
+	return o.set("ass-events", data)
+}
+
+// SetOptions changes resolution, order or visibility of the overlay and redraws it with provided data
+func (o *ASSOverlay) SetOptions(data string, opts ASSOverlayOptions) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.removed {
+		return &OpError{Op: "command", Name: "osd-overlay", Code: ErrInvalidParameter, Err: errors.New("overlay was removed")}
+	}
+	o.opts = opts
+	return o.set("ass-events", data)
+}
+
+// Remove hides the overlay. It is safe to call it multiple times.
+func (o *ASSOverlay) Remove() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.removed {
+		return nil
+	}
+	o.removed = true
+	return o.set("none", "")
+}
+
+func (o *ASSOverlay) set(format, data string) error {
+	_, err := o.m.CommandNode(&Node{Format: FormatNodeMap, Data: NodeMap{
+		"name":   {Data: "osd-overlay", Format: FormatString},
+		"id":     {Data: o.id, Format: FormatInt64},
+		"format": {Data: format, Format: FormatString},
+		"data":   {Data: data, Format: FormatString},
+		"res_x":  {Data: int64(o.opts.ResX), Format: FormatInt64},
+		"res_y":  {Data: int64(o.opts.ResY), Format: FormatInt64},
+		"z":      {Data: int64(o.opts.Z), Format: FormatInt64},
+		"hidden": {Data: o.opts.Hidden, Format: FormatFlag},
+	}})
+	return err
+}
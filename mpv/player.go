@@ -0,0 +1,115 @@
+package mpv
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Player is set of methods needed to control mpv, independent of the backend.
+//
+// It is implemented by *Mpv (embedded libmpv) and by *ipc.Client (external mpv process
+// controlled through JSON IPC), so application code can depend on Player and choose the backend
+// at runtime.
+type Player interface {
+	Command(args []string) error
+	CommandReturn(args []string) (*Node, error)
+	CommandNode(args *Node) (*Node, error)
+
+	GetProperty(name string, format Format) (interface{}, error)
+	GetPropertyString(name string) (string, error)
+	SetProperty(name string, value interface{}, format Format) error
+	SetPropertyString(name, value string) error
+
+	ObserveProperty(name string, id uint64, format Format) error
+	UnObserveProperty(id uint64) (int, error)
+
+	EventWait(timeout float64) *Event
+	Events(ctx context.Context) (<-chan *Event, error)
+
+	// Close disconnects from the player. Player must not be used after Close.
+	Close() error
+}
+
+// Subscriber is implemented by players which can call function every time property changes
+// (*Mpv, *ipc.Client and *mpvfake.Mpv). It is used by OnChange methods of Playlist, Tracks and Chapters.
+type Subscriber interface {
+	// SubscribeProperty calls fn with the current value of the property and then with every change.
+	// fn is called from goroutine which reads events, so events must be read for it to run.
+	// Closing returned value stops the subscription.
+	SubscribeProperty(name string, format Format, fn func(EProperty)) (io.Closer, error)
+}
+
+// subscribe subscribes to the property if p implements Subscriber
+func subscribe(p Player, name string, format Format, fn func(EProperty)) (io.Closer, error) {
+	s, ok := p.(Subscriber)
+	if !ok {
+		return nil, &OpError{Op: "observe_property", Name: name, Code: ErrNotImplemented, Err: fmt.Errorf("%T does not implement Subscriber", p)}
+	}
+	return s.SubscribeProperty(name, format, fn)
+}
+
+// getBool returns value of property with FormatFlag
+func getBool(p Player, name string) (bool, error) {
+	value, err := p.GetProperty(name, FormatFlag)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("property '%s' returned %T instead of bool", name, value)
+	}
+	return result, nil
+}
+
+// getInt64 returns value of property with FormatInt64
+func getInt64(p Player, name string) (int64, error) {
+	value, err := p.GetProperty(name, FormatInt64)
+	if err != nil {
+		return 0, err
+	}
+	result, ok := value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("property '%s' returned %T instead of int64", name, value)
+	}
+	return result, nil
+}
+
+// getFloat64 returns value of property with FormatDouble
+func getFloat64(p Player, name string) (float64, error) {
+	value, err := p.GetProperty(name, FormatDouble)
+	if err != nil {
+		return 0, err
+	}
+	result, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("property '%s' returned %T instead of float64", name, value)
+	}
+	return result, nil
+}
+
+// getString returns value of property with FormatString
+func getString(p Player, name string) (string, error) {
+	value, err := p.GetProperty(name, FormatString)
+	if err != nil {
+		return "", err
+	}
+	result, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("property '%s' returned %T instead of string", name, value)
+	}
+	return result, nil
+}
+
+// getNode returns value of property with FormatNode
+func getNode(p Player, name string) (*Node, error) {
+	value, err := p.GetProperty(name, FormatNode)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := value.(*Node)
+	if !ok {
+		return nil, fmt.Errorf("property '%s' returned %T instead of *Node", name, value)
+	}
+	return result, nil
+}
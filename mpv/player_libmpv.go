@@ -0,0 +1,18 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+// Playlist returns manager of the playlist
+func (m *Mpv) Playlist() *Playlist {
+	return NewPlaylist(m)
+}
+
+// Tracks returns manager of tracks of current file
+func (m *Mpv) Tracks() *Tracks {
+	return NewTracks(m)
+}
+
+// Chapters returns manager of chapters of current file
+func (m *Mpv) Chapters() *Chapters {
+	return NewChapters(m)
+}
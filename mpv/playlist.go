@@ -0,0 +1,142 @@
+package mpv
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// PlaylistEntry is single entry of "playlist" property
+type PlaylistEntry struct {
+	ID       int64  `mpv:"id"`
+	Filename string `mpv:"filename"`
+	Title    string `mpv:"title"`
+	Current  bool   `mpv:"current"`
+	Playing  bool   `mpv:"playing"`
+}
+
+// Playlist manages playlist of the player.
+//
+// All indexes are 0-based positions in the playlist, as returned by Entries.
+type Playlist struct {
+	p Player
+}
+
+// NewPlaylist returns manager of the playlist of p
+func NewPlaylist(p Player) *Playlist {
+	return &Playlist{p: p}
+}
+
+// Entries returns current entries of the playlist
+func (p *Playlist) Entries() ([]PlaylistEntry, error) {
+	node, err := getNode(p.p, "playlist")
+	if err != nil {
+		return nil, err
+	}
+	return decodePlaylist(node)
+}
+
+func decodePlaylist(node *Node) ([]PlaylistEntry, error) {
+	var entries []PlaylistEntry
+	if err := Unmarshal(node, &entries); err != nil {
+		return nil, &OpError{Op: "get_property", Name: "playlist", Code: ErrPropertyFormat, Err: err}
+	}
+	return entries, nil
+}
+
+// Len returns number of entries in the playlist
+func (p *Playlist) Len() (int, error) {
+	count, err := getInt64(p.p, "playlist-count")
+	return int(count), err
+}
+
+// Current returns index of current entry, -1 if there is none
+func (p *Playlist) Current() (int, error) {
+	pos, err := getInt64(p.p, "playlist-pos")
+	return int(pos), err
+}
+
+// Append adds url at the end of the playlist. Playback does not start if it was stopped.
+func (p *Playlist) Append(url string) error {
+	return p.p.Command([]string{"loadfile", url, "append"})
+}
+
+// InsertAt adds url to the playlist at provided index.
+// Index equal or greater than length of the playlist appends it. Requires mpv 0.38 or newer.
+func (p *Playlist) InsertAt(index int, url string) error {
+	if index < 0 {
+		return &OpError{Op: "command", Name: "loadfile", Code: ErrInvalidParameter, Err: errors.New("negative playlist index")}
+	}
+	return p.p.Command([]string{"loadfile", url, "insert-at", strconv.Itoa(index)})
+}
+
+// Move moves entry from index from, so after the move it is at index to.
+//
+// Note that playlist-move command of mpv inserts the entry before the target index,
+// which differs by one when moving entries forward.
+func (p *Playlist) Move(from, to int) error {
+	if from == to {
+		return nil
+	}
+	if to > from {
+		to++
+	}
+	return p.p.Command([]string{"playlist-move", strconv.Itoa(from), strconv.Itoa(to)})
+}
+
+// Remove removes entry at index. Removing current entry stops its playback.
+func (p *Playlist) Remove(index int) error {
+	return p.p.Command([]string{"playlist-remove", strconv.Itoa(index)})
+}
+
+// Clear removes all entries from the playlist, including the current one
+// (playlist-clear command of mpv keeps it).
+func (p *Playlist) Clear() error {
+	if err := p.p.Command([]string{"playlist-clear"}); err != nil {
+		return err
+	}
+
+	count, err := p.Len()
+	if err != nil || count == 0 {
+		return err
+	}
+	return p.Remove(0)
+}
+
+// Shuffle shuffles the playlist
+func (p *Playlist) Shuffle() error {
+	return p.p.Command([]string{"playlist-shuffle"})
+}
+
+// Unshuffle reverts the last Shuffle
+func (p *Playlist) Unshuffle() error {
+	return p.p.Command([]string{"playlist-unshuffle"})
+}
+
+// PlayIndex starts playback of entry at index
+func (p *Playlist) PlayIndex(index int) error {
+	return p.p.Command([]string{"playlist-play-index", strconv.Itoa(index)})
+}
+
+// Next starts playback of the next entry
+func (p *Playlist) Next() error {
+	return p.p.Command([]string{"playlist-next"})
+}
+
+// Prev starts playback of the previous entry
+func (p *Playlist) Prev() error {
+	return p.p.Command([]string{"playlist-prev"})
+}
+
+// OnChange calls fn with all entries every time the playlist changes.
+// Player must implement Subscriber.
+func (p *Playlist) OnChange(fn func([]PlaylistEntry)) (io.Closer, error) {
+	return subscribe(p.p, "playlist", FormatNode, func(e EProperty) {
+		node, _ := e.Property.(*Node)
+		entries, err := decodePlaylist(node)
+		if err != nil {
+			return
+		}
+		fn(entries)
+	})
+}
@@ -0,0 +1,84 @@
+package mpv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+	"github.com/HuntClauss/mpvgo/mpvfake"
+)
+
+func commandArgs(m *mpvfake.Mpv) [][]string {
+	var args [][]string
+	for _, c := range m.Commands() {
+		args = append(args, c.Args)
+	}
+	return args
+}
+
+func TestPlaylistMove(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+		want     [][]string
+	}{
+		{"forward", 0, 2, [][]string{{"playlist-move", "0", "3"}}},
+		{"backward", 2, 0, [][]string{{"playlist-move", "2", "0"}}},
+		{"same index", 1, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mpvfake.New("test")
+			if err := mpv.NewPlaylist(m).Move(tt.from, tt.to); err != nil {
+				t.Fatal(err)
+			}
+			if got := commandArgs(m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaylistInsertAt(t *testing.T) {
+	m := mpvfake.New("test")
+	playlist := mpv.NewPlaylist(m)
+
+	if err := playlist.InsertAt(1, "a.mkv"); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"loadfile", "a.mkv", "insert-at", "1"}}
+	if got := commandArgs(m); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+
+	if err := playlist.InsertAt(-1, "a.mkv"); err == nil {
+		t.Error("expected error for negative index")
+	}
+}
+
+func TestPlaylistClear(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int64 // playlist-count after playlist-clear
+		want  [][]string
+	}{
+		{"current entry", 1, [][]string{{"playlist-clear"}, {"playlist-remove", "0"}}},
+		{"empty", 0, [][]string{{"playlist-clear"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mpvfake.New("test")
+			if err := m.SetProperty("playlist-count", tt.count, mpv.FormatInt64); err != nil {
+				t.Fatal(err)
+			}
+			if err := mpv.NewPlaylist(m).Clear(); err != nil {
+				t.Fatal(err)
+			}
+			if got := commandArgs(m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+// #include "utils.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"errors"
+	"fmt"
+	"image"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+// RenderUpdateFlag is returned by RenderContext.Update
+type RenderUpdateFlag uint64
+
+const (
+	// RenderUpdateFrame means a new video frame must be rendered
+	RenderUpdateFrame RenderUpdateFlag = 1 << 0
+)
+
+// RenderContext renders video frames into memory using the software renderer of libmpv.
+//
+// It is not tied to any window or GPU, so it can be used on headless machines.
+type RenderContext struct {
+	ctx *C.mpv_render_context
+
+	mu       sync.Mutex
+	callback cgo.Handle
+}
+
+// CreateRenderContext creates new render context which uses software renderer ("sw" API).
+//
+// Video output of the mpv instance must be set to "libmpv" (vo=libmpv) for frames to be
+// delivered to the render context. The context must be freed with Free before the
+// mpv handle is destroyed.
+func (m *Mpv) CreateRenderContext() (*RenderContext, error) {
+	var ctx *C.mpv_render_context
+	code := C.createSWRenderContext(&ctx, m.ctx)
+	if err := newOpError("render_context_create", "sw", code); err != nil {
+		return nil, err
+	}
+	return &RenderContext{ctx: ctx}, nil
+}
+
+// SetUpdateCallback sets callback which is called every time when new frame could be drawn
+// or Update should be called.
+//
+// Callback is called from mpv internal threads, so it must not call any mpv functions
+// and should return quickly (for example notify other goroutine). Passing nil removes the callback.
+func (r *RenderContext) SetUpdateCallback(callback func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.callback
+	r.callback = 0
+	if callback != nil {
+		r.callback = cgo.NewHandle(callback)
+	}
+
+	C.setRenderUpdateCallback(r.ctx, C.uintptr_t(r.callback))
+	if old != 0 {
+		old.Delete()
+	}
+}
+
+// Update must be called after update callback was invoked.
+// Returned flags tells what should be done next.
+func (r *RenderContext) Update() RenderUpdateFlag {
+	return RenderUpdateFlag(C.mpv_render_context_update(r.ctx))
+}
+
+// Render renders current video frame into provided image.
+//
+// Size of the frame is size of the image bounds.
+func (r *RenderContext) Render(img *image.RGBA) error {
+	size := img.Rect.Size()
+	if size.X <= 0 || size.Y <= 0 {
+		return errors.New("cannot render into empty image")
+	}
+
+	pix := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):]
+	if err := r.render(pix, size.X, size.Y, img.Stride, "rgb0"); err != nil {
+		return err
+	}
+
+	// "rgb0" leaves padding byte undefined, but image.RGBA treats it as alpha
+	for y := 0; y < size.Y; y++ {
+		row := pix[y*img.Stride : y*img.Stride+size.X*4]
+		for i := 3; i < len(row); i += 4 {
+			row[i] = 0xff
+		}
+	}
+	return nil
+}
+
+// swFormats maps pixel formats supported by mpv software renderer to their size in bytes
+var swFormats = map[string]int{
+	"rgb0":  4,
+	"bgr0":  4,
+	"0bgr":  4,
+	"0rgb":  4,
+	"rgb24": 3,
+}
+
+// RenderBuffer renders current video frame into caller provided buffer.
+//
+// format is name of pixel format supported by mpv software renderer
+// ("rgb0", "bgr0", "0bgr", "0rgb" or "rgb24").
+// stride is number of bytes between beginning of two consecutive rows, at least width of the row.
+func (r *RenderContext) RenderBuffer(buf []byte, width, height, stride int, format string) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid frame size %dx%d", width, height)
+	}
+	pixelSize, ok := swFormats[format]
+	if !ok {
+		return fmt.Errorf("unsupported pixel format %q", format)
+	}
+	if stride < width*pixelSize {
+		return fmt.Errorf("stride %d is smaller than row of %d pixels in format %s", stride, width, format)
+	}
+	if len(buf) < stride*height {
+		return fmt.Errorf("buffer is too small for %dx%d frame with stride %d", width, height, stride)
+	}
+	return r.render(buf, width, height, stride, format)
+}
+
+func (r *RenderContext) render(buf []byte, width, height, stride int, format string) error {
+	cformat := C.CString(format)
+	defer C.free(unsafe.Pointer(cformat))
+
+	code := C.renderSW(r.ctx, C.int(width), C.int(height), cformat, C.size_t(stride), unsafe.Pointer(&buf[0]))
+	return newOpError("render_context_render", format, code)
+}
+
+// ReportSwap tells mpv that rendered frame was displayed.
+// This is optional, but can improve timing of video playback.
+func (r *RenderContext) ReportSwap() {
+	C.mpv_render_context_report_swap(r.ctx)
+}
+
+// Free destroys render context and removes update callback.
+func (r *RenderContext) Free() {
+	C.mpv_render_context_free(r.ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.callback != 0 {
+		r.callback.Delete()
+		r.callback = 0
+	}
+}
+
+//export goRenderUpdateCallback
+func goRenderUpdateCallback(handle C.uintptr_t) {
+	if callback, ok := cgo.Handle(handle).Value().(func()); ok {
+		callback()
+	}
+}
@@ -0,0 +1,267 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// MessageHandler handles client message sent with "script-message-to <client> <name> <args...>".
+// It is called from goroutine which reads events, so it should not block.
+type MessageHandler func(args []string)
+
+// MethodHandler handles call of method registered with HandleMethod.
+// Result is converted with Marshal.
+type MethodHandler func(ctx context.Context, args NodeList) (interface{}, error)
+
+// RPCError is error reported by the other side of the call
+type RPCError struct {
+	Target  string
+	Method  string
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc call of '%s' in '%s' failed: %s", e.Method, e.Target, e.Message)
+}
+
+// ErrUnknownMethod is sent to the caller when called method is not registered.
+// Caller receives it as RPCError with the same message.
+var ErrUnknownMethod = errors.New("unknown method")
+
+type rpcState struct {
+	mu       sync.Mutex
+	messages map[string]MessageHandler
+	methods  map[string]MethodHandler
+	calls    map[string]chan rpcReply
+}
+
+type rpcReply struct {
+	result   string
+	err      string
+	shutdown bool
+}
+
+func (m *Mpv) rpc() *rpcState {
+	m.state.mu.Lock()
+	rpc := m.state.rpc
+	created := rpc == nil
+	if created {
+		rpc = &rpcState{
+			messages: map[string]MessageHandler{},
+			methods:  map[string]MethodHandler{},
+			calls:    map[string]chan rpcReply{},
+		}
+		m.state.rpc = rpc
+	}
+	m.state.mu.Unlock()
+
+	if created {
+		m.state.addListener(func(ev *Event) { m.handleRPCEvent(rpc, ev) })
+	}
+	return rpc
+}
+
+// HandleMessage registers handler of client messages with provided name
+// (the first argument of script-message-to). nil fn removes the handler.
+// Events must be read (EventWait or Events) for handlers to run.
+func (m *Mpv) HandleMessage(name string, fn MessageHandler) {
+	rpc := m.rpc()
+	rpc.mu.Lock()
+	defer rpc.mu.Unlock()
+
+	if fn == nil {
+		delete(rpc.messages, name)
+		return
+	}
+	rpc.messages[name] = fn
+}
+
+// HandleMethod registers method which can be called by scripts and other clients. nil fn removes the method.
+//
+// Calls use following client messages, where arguments and result are JSON:
+//
+//	script-message-to <client> rpc-call <method> <call-id> <reply-to> <args-array>
+//	script-message-to <reply-to> rpc-reply <call-id> <result> <error>
+//
+// error is empty string on success. Each call runs in new goroutine, ctx is cancelled when the handle shuts down
+// or is destroyed. Destroy and Terminate wait until running methods return, so methods must not call them.
+// Events must be read (EventWait or Events) for methods to be called.
+func (m *Mpv) HandleMethod(name string, fn MethodHandler) {
+	rpc := m.rpc()
+	rpc.mu.Lock()
+	defer rpc.mu.Unlock()
+
+	if fn == nil {
+		delete(rpc.methods, name)
+		return
+	}
+	rpc.methods[name] = fn
+}
+
+// CallScript calls method of script (or other client) with provided name and waits for its result,
+// using convention described in HandleMethod. Arguments are converted with Marshal.
+//
+// If ctx is done first, ctx.Err() is returned. Events must be read (EventWait or Events) for reply to be received.
+func (m *Mpv) CallScript(ctx context.Context, script, method string, args ...interface{}) (*Node, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	encoded, err := encodeRPC(args)
+	if err != nil {
+		return nil, &OpError{Op: "command", Name: "script-message-to", Code: ErrInvalidParameter, Err: err}
+	}
+
+	rpc := m.rpc()
+	id := strconv.FormatUint(m.state.nextID(), 10)
+	reply := make(chan rpcReply, 1)
+
+	rpc.mu.Lock()
+	rpc.calls[id] = reply
+	rpc.mu.Unlock()
+
+	defer func() {
+		rpc.mu.Lock()
+		delete(rpc.calls, id)
+		rpc.mu.Unlock()
+	}()
+
+	err = m.Command([]string{"script-message-to", script, "rpc-call", method, id, m.ClientName(), encoded})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-reply:
+		if r.shutdown {
+			return nil, ErrShutdown
+		}
+		if r.err != "" {
+			return nil, &RPCError{Target: script, Method: method, Message: r.err}
+		}
+		var result Node
+		if err := result.UnmarshalJSON([]byte(r.result)); err != nil {
+			return nil, &RPCError{Target: script, Method: method, Message: "invalid result: " + err.Error()}
+		}
+		return &result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func encodeRPC(value interface{}) (string, error) {
+	node, err := Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (m *Mpv) handleRPCEvent(rpc *rpcState, ev *Event) {
+	switch ev.EventID {
+	case EventShutdown:
+		rpc.mu.Lock()
+		for id, reply := range rpc.calls {
+			reply <- rpcReply{shutdown: true}
+			delete(rpc.calls, id)
+		}
+		rpc.mu.Unlock()
+		return
+	case EventClientMessage:
+	default:
+		return
+	}
+
+	args, _ := ev.Data.(EClientMessage)
+	if len(args) == 0 {
+		return
+	}
+
+	switch args[0] {
+	case "rpc-call":
+		if len(args) < 5 {
+			return
+		}
+		rpc.mu.Lock()
+		fn := rpc.methods[args[1]]
+		rpc.mu.Unlock()
+
+		m.state.startTask(func(ctx context.Context) { m.runMethod(ctx, fn, args[2], args[3], args[4:]) })
+	case "rpc-reply":
+		if len(args) < 4 {
+			return
+		}
+		rpc.mu.Lock()
+		reply, ok := rpc.calls[args[1]]
+		delete(rpc.calls, args[1])
+		rpc.mu.Unlock()
+
+		if ok {
+			reply <- rpcReply{result: args[2], err: args[3]}
+		}
+	default:
+		rpc.mu.Lock()
+		fn := rpc.messages[args[0]]
+		rpc.mu.Unlock()
+
+		if fn != nil {
+			fn(args[1:])
+		}
+	}
+}
+
+// runMethod runs method handler and sends its result to the caller, unless the handle was closed meanwhile
+func (m *Mpv) runMethod(ctx context.Context, fn MethodHandler, id, replyTo string, rest []string) {
+	result, err := func() (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("method panicked: %v", r)
+			}
+		}()
+
+		if fn == nil {
+			return nil, ErrUnknownMethod
+		}
+
+		var args Node
+		if len(rest) > 0 && rest[0] != "" {
+			if err := args.UnmarshalJSON([]byte(rest[0])); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		list, _ := args.Data.(NodeList)
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		remove := m.state.addListener(func(ev *Event) {
+			if ev.EventID == EventShutdown {
+				cancel()
+			}
+		})
+		defer remove()
+
+		return fn(ctx, list)
+	}()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	encoded, message := "null", ""
+	if err == nil {
+		encoded, err = encodeRPC(result)
+	}
+	if err != nil {
+		encoded, message = "null", err.Error()
+	}
+	_ = m.Command([]string{"script-message-to", replyTo, "rpc-reply", id, encoded, message})
+}
@@ -0,0 +1,78 @@
+package mpv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+)
+
+// rawScreenshot is result of screenshot-raw command
+type rawScreenshot struct {
+	Width  int    `mpv:"w"`
+	Height int    `mpv:"h"`
+	Stride int    `mpv:"stride"`
+	Format string `mpv:"format"`
+	Data   []byte `mpv:"data"`
+}
+
+func decodeScreenshot(node *Node) (image.Image, error) {
+	var raw rawScreenshot
+	if err := Unmarshal(node, &raw); err != nil {
+		return nil, err
+	}
+
+	pixelSize := 4
+	if raw.Format == "rgba64" {
+		pixelSize = 8
+	}
+	if raw.Width <= 0 || raw.Height <= 0 || raw.Stride < raw.Width*pixelSize {
+		return nil, fmt.Errorf("invalid screenshot size %dx%d with stride %d", raw.Width, raw.Height, raw.Stride)
+	}
+	if len(raw.Data) < raw.Stride*(raw.Height-1)+raw.Width*pixelSize {
+		return nil, fmt.Errorf("screenshot data is too short: %d bytes", len(raw.Data))
+	}
+
+	rect := image.Rect(0, 0, raw.Width, raw.Height)
+	switch raw.Format {
+	case "bgr0":
+		img := image.NewRGBA(rect)
+		copyPixels(img.Pix, img.Stride, raw, pixelSize, func(dst, src []byte) {
+			dst[0], dst[1], dst[2], dst[3] = src[2], src[1], src[0], 0xff
+		})
+		return img, nil
+	case "bgra":
+		img := image.NewNRGBA(rect)
+		copyPixels(img.Pix, img.Stride, raw, pixelSize, func(dst, src []byte) {
+			dst[0], dst[1], dst[2], dst[3] = src[2], src[1], src[0], src[3]
+		})
+		return img, nil
+	case "rgba":
+		img := image.NewNRGBA(rect)
+		copyPixels(img.Pix, img.Stride, raw, pixelSize, func(dst, src []byte) {
+			copy(dst, src[:4])
+		})
+		return img, nil
+	case "rgba64":
+		// mpv uses native (little endian) byte order, image.NRGBA64 uses big endian
+		img := image.NewNRGBA64(rect)
+		copyPixels(img.Pix, img.Stride, raw, pixelSize, func(dst, src []byte) {
+			for i := 0; i < 8; i += 2 {
+				binary.BigEndian.PutUint16(dst[i:], binary.LittleEndian.Uint16(src[i:]))
+			}
+		})
+		return img, nil
+	}
+	return nil, fmt.Errorf("unsupported screenshot format %q", raw.Format)
+}
+
+// copyPixels converts rows of raw screenshot into pix using convert for every pixel.
+// Source and destination pixels have the same size.
+func copyPixels(pix []byte, stride int, raw rawScreenshot, pixelSize int, convert func(dst, src []byte)) {
+	for y := 0; y < raw.Height; y++ {
+		src := raw.Data[y*raw.Stride:]
+		dst := pix[y*stride:]
+		for x := 0; x < raw.Width; x++ {
+			convert(dst[x*pixelSize:], src[x*pixelSize:])
+		}
+	}
+}
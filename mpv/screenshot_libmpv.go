@@ -0,0 +1,30 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"image"
+)
+
+// ScreenshotImage takes screenshot with screenshot-raw command and returns it as image,
+// without saving it into file.
+//
+// Returned image is *image.RGBA for "bgr0" format (the default of mpv),
+// *image.NRGBA for "bgra" and "rgba", and *image.NRGBA64 for "rgba64".
+func (m *Mpv) ScreenshotImage(mode ScreenshotMode) (image.Image, error) {
+	args := NodeMap{"name": {Data: "screenshot-raw", Format: FormatString}}
+	if mode != "" {
+		args["flags"] = Node{Data: string(mode), Format: FormatString}
+	}
+
+	result, err := m.CommandNode(&Node{Data: args, Format: FormatNodeMap})
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := decodeScreenshot(result)
+	if err != nil {
+		return nil, &OpError{Op: "command", Name: "screenshot-raw", Code: ErrGeneric, Err: err}
+	}
+	return img, nil
+}
@@ -0,0 +1,103 @@
+package mpv
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func screenshotNode(w, h, stride int64, format string, data []byte) *Node {
+	return &Node{Format: FormatNodeMap, Data: NodeMap{
+		"w":      {Data: w, Format: FormatInt64},
+		"h":      {Data: h, Format: FormatInt64},
+		"stride": {Data: stride, Format: FormatInt64},
+		"format": {Data: format, Format: FormatString},
+		"data":   {Data: data, Format: FormatByteArray},
+	}}
+}
+
+func TestDecodeScreenshot(t *testing.T) {
+	tests := []struct {
+		name   string
+		node   *Node
+		pixels []color.Color // pixels in row order
+	}{
+		{
+			name: "bgr0 with padding",
+			node: screenshotNode(2, 2, 12, "bgr0", []byte{
+				1, 2, 3, 0, 4, 5, 6, 0, 0xee, 0xee, 0xee, 0xee,
+				7, 8, 9, 0, 10, 11, 12, 0,
+			}),
+			pixels: []color.Color{
+				color.RGBA{3, 2, 1, 0xff}, color.RGBA{6, 5, 4, 0xff},
+				color.RGBA{9, 8, 7, 0xff}, color.RGBA{12, 11, 10, 0xff},
+			},
+		},
+		{
+			name:   "bgra",
+			node:   screenshotNode(1, 1, 4, "bgra", []byte{1, 2, 3, 4}),
+			pixels: []color.Color{color.NRGBA{3, 2, 1, 4}},
+		},
+		{
+			name:   "rgba",
+			node:   screenshotNode(2, 1, 8, "rgba", []byte{1, 2, 3, 4, 5, 6, 7, 8}),
+			pixels: []color.Color{color.NRGBA{1, 2, 3, 4}, color.NRGBA{5, 6, 7, 8}},
+		},
+		{
+			name:   "rgba64",
+			node:   screenshotNode(1, 1, 8, "rgba64", []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}),
+			pixels: []color.Color{color.NRGBA64{0x0201, 0x0403, 0x0605, 0x0807}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeScreenshot(tt.node)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bounds := img.Bounds()
+			if bounds.Dx()*bounds.Dy() != len(tt.pixels) {
+				t.Fatalf("got %v image, want %d pixels", bounds, len(tt.pixels))
+			}
+			for i, want := range tt.pixels {
+				x, y := i%bounds.Dx(), i/bounds.Dx()
+				if got := img.At(x, y); got != want {
+					t.Errorf("pixel (%d, %d) = %#v, want %#v", x, y, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeScreenshotTypes(t *testing.T) {
+	img, err := decodeScreenshot(screenshotNode(1, 1, 4, "bgr0", make([]byte, 4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := img.(*image.RGBA); !ok {
+		t.Errorf("bgr0 decoded as %T, want *image.RGBA", img)
+	}
+}
+
+func TestDecodeScreenshotErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		node *Node
+	}{
+		{"unsupported format", screenshotNode(1, 1, 4, "yuv420p", make([]byte, 4))},
+		{"data too short", screenshotNode(2, 2, 8, "rgba", make([]byte, 12))},
+		{"stride too small", screenshotNode(2, 1, 4, "rgba", make([]byte, 8))},
+		{"empty", screenshotNode(0, 0, 0, "rgba", nil)},
+		{"not a map", &Node{Data: "image", Format: FormatString}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeScreenshot(tt.node); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+// #include "utils.h"
+// #include <stdlib.h>
+import "C"
+import (
+	"io"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// StreamOpener opens stream for uri (including protocol prefix, e.g. "gostream://foo").
+//
+// Returned reader is used by mpv to read data. Optionally it can implement:
+//   - io.Seeker, so the stream is seekable,
+//   - Size() int64, to report size of the stream (otherwise io.Seeker is used, if available),
+//   - Cancel(), to abort blocking Read when mpv wants to close the stream,
+//   - io.Closer, which is called when mpv does not need the stream anymore.
+type StreamOpener func(uri string) (io.Reader, error)
+
+type stream struct {
+	reader io.Reader
+}
+
+// maxEmptyReads is number of reads returning no data and no error after which reading fails,
+// the same limit as used by bufio
+const maxEmptyReads = 100
+
+// RegisterStreamProtocol adds custom protocol backed by Go reader.
+// After that files like "protocol://..." can be loaded with "loadfile" command.
+//
+// opener is called from mpv internal threads. Panics of opener and the reader are recovered
+// and reported to mpv as errors. Protocol cannot be unregistered, it is available as long as player core exists.
+func (m *Mpv) RegisterStreamProtocol(protocol string, opener StreamOpener) error {
+	cprotocol := C.CString(protocol)
+	defer C.free(unsafe.Pointer(cprotocol))
+
+	handle := cgo.NewHandle(opener)
+	code := C.addStreamProtocol(m.ctx, cprotocol, C.uintptr_t(handle))
+	if err := newOpError("stream_cb_add_ro", protocol, code); err != nil {
+		handle.Delete()
+		return err
+	}
+	return nil
+}
+
+//export goStreamOpen
+func goStreamOpen(handle C.uintptr_t, uri *C.char, info *C.mpv_stream_cb_info) (result C.int) {
+	defer func() {
+		if recover() != nil {
+			result = C.int(ErrGeneric)
+		}
+	}()
+
+	opener := cgo.Handle(handle).Value().(StreamOpener)
+
+	reader, err := opener(C.GoString(uri))
+	if err != nil {
+		return C.int(ErrLoadingFailed)
+	}
+	if reader == nil {
+		return C.int(ErrGeneric)
+	}
+
+	var seekable, cancelable C.int
+	if _, ok := reader.(io.Seeker); ok {
+		seekable = 1
+	}
+	if _, ok := reader.(interface{ Cancel() }); ok {
+		cancelable = 1
+	}
+
+	cookie := cgo.NewHandle(&stream{reader: reader})
+	C.setStreamCallbacks(info, C.uintptr_t(cookie), seekable, cancelable)
+	return 0
+}
+
+//export goStreamRead
+func goStreamRead(cookie C.uintptr_t, buf *C.char, nbytes C.uint64_t) (result C.int64_t) {
+	defer func() {
+		if recover() != nil {
+			result = -1
+		}
+	}()
+
+	s := cgo.Handle(cookie).Value().(*stream)
+	if nbytes == 0 {
+		return 0
+	}
+
+	data := unsafe.Slice((*byte)(unsafe.Pointer(buf)), int(nbytes))
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := s.reader.Read(data)
+		if n > 0 {
+			return C.int64_t(n)
+		}
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			return -1
+		}
+	}
+	return -1
+}
+
+//export goStreamSeek
+func goStreamSeek(cookie C.uintptr_t, offset C.int64_t) (result C.int64_t) {
+	defer func() {
+		if recover() != nil {
+			result = C.int64_t(ErrGeneric)
+		}
+	}()
+
+	s := cgo.Handle(cookie).Value().(*stream)
+
+	pos, err := s.reader.(io.Seeker).Seek(int64(offset), io.SeekStart)
+	if err != nil {
+		return C.int64_t(ErrGeneric)
+	}
+	return C.int64_t(pos)
+}
+
+//export goStreamSize
+func goStreamSize(cookie C.uintptr_t) (result C.int64_t) {
+	defer func() {
+		if recover() != nil {
+			result = C.int64_t(ErrGeneric)
+		}
+	}()
+
+	s := cgo.Handle(cookie).Value().(*stream)
+
+	if sized, ok := s.reader.(interface{ Size() int64 }); ok {
+		return C.int64_t(sized.Size())
+	}
+
+	seeker, ok := s.reader.(io.Seeker)
+	if !ok {
+		return C.int64_t(ErrUnsupported)
+	}
+
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return C.int64_t(ErrUnsupported)
+	}
+	size, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return C.int64_t(ErrUnsupported)
+	}
+	if _, err := seeker.Seek(current, io.SeekStart); err != nil {
+		return C.int64_t(ErrGeneric)
+	}
+	return C.int64_t(size)
+}
+
+//export goStreamClose
+func goStreamClose(cookie C.uintptr_t) {
+	defer func() { _ = recover() }()
+
+	handle := cgo.Handle(cookie)
+	s := handle.Value().(*stream)
+	handle.Delete()
+
+	if closer, ok := s.reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+//export goStreamCancel
+func goStreamCancel(cookie C.uintptr_t) {
+	defer func() { _ = recover() }()
+
+	s := cgo.Handle(cookie).Value().(*stream)
+	s.reader.(interface{ Cancel() }).Cancel()
+}
@@ -0,0 +1,95 @@
+package mpv
+
+import (
+	"io"
+	"sync"
+)
+
+// PropertySubscribers keeps functions registered with SubscribeProperty of players
+// which use separate observer for every subscription (*ipc.Client and *mpvfake.Mpv).
+// It is safe for concurrent use.
+type PropertySubscribers struct {
+	mu           sync.Mutex
+	nextID       uint64
+	fns          map[uint64]func(EProperty)
+	panicHandler func(ev *Event, recovered interface{})
+}
+
+// NewPropertySubscribers creates subscribers which allocate observer IDs starting with firstID
+func NewPropertySubscribers(firstID uint64) *PropertySubscribers {
+	return &PropertySubscribers{nextID: firstID, fns: map[uint64]func(EProperty){}}
+}
+
+// Subscribe observes the property on p with newly allocated ID and registers fn for its changes.
+// Closing returned value removes fn and the observer.
+func (s *PropertySubscribers) Subscribe(p Player, name string, format Format, fn func(EProperty)) (io.Closer, error) {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.fns[id] = fn
+	s.mu.Unlock()
+
+	if err := p.ObserveProperty(name, id, format); err != nil {
+		s.remove(id)
+		return nil, err
+	}
+	return &propertySubscription{s: s, p: p, id: id}, nil
+}
+
+func (s *PropertySubscribers) remove(id uint64) {
+	s.mu.Lock()
+	delete(s.fns, id)
+	s.mu.Unlock()
+}
+
+// Notify calls function subscribed to observer which sent the event. Other events are ignored.
+// Panic of the function is recovered and passed to the panic handler.
+func (s *PropertySubscribers) Notify(ev *Event) {
+	if ev.EventID != EventPropertyChange {
+		return
+	}
+
+	s.mu.Lock()
+	fn := s.fns[ev.ID]
+	handler := s.panicHandler
+	s.mu.Unlock()
+
+	if fn != nil {
+		callRecovered(ev, handler, func() { fn(ev.Data.(EProperty)) })
+	}
+}
+
+// SetPanicHandler sets function called when subscriber panics, like Mpv.SetPanicHandler.
+// nil fn removes the handler.
+func (s *PropertySubscribers) SetPanicHandler(fn func(ev *Event, recovered interface{})) {
+	s.mu.Lock()
+	s.panicHandler = fn
+	s.mu.Unlock()
+}
+
+type propertySubscription struct {
+	s     *PropertySubscribers
+	p     Player
+	id    uint64
+	close sync.Once
+}
+
+// Close removes the subscription and its observer. It is safe to call it multiple times.
+func (sub *propertySubscription) Close() error {
+	var err error
+	sub.close.Do(func() {
+		sub.s.remove(sub.id)
+		_, err = sub.p.UnObserveProperty(sub.id)
+	})
+	return err
+}
+
+// callRecovered calls callback for event. Its panic is recovered and passed to handler, if it is not nil.
+func callRecovered(ev *Event, handler func(ev *Event, recovered interface{}), fn func()) {
+	defer func() {
+		if r := recover(); r != nil && handler != nil {
+			handler(ev, r)
+		}
+	}()
+	fn()
+}
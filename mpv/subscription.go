@@ -0,0 +1,196 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+import (
+	"io"
+	"sync"
+)
+
+// Subscription is a handle of property observer registered with Subscribe
+type Subscription struct {
+	m      *Mpv
+	obs    *observation
+	fn     func(EProperty)
+	close  sync.Once
+	closed bool // guarded by mutex of the handle state
+}
+
+type observationKey struct {
+	name   string
+	format Format
+}
+
+// observation is single mpv property observer shared by all subscribers
+// of the same property and format.
+type observation struct {
+	id   uint64
+	key  observationKey
+	subs []*Subscription
+	last *EProperty
+
+	// ready is closed after ObserveProperty returns, err is its result
+	ready chan struct{}
+	err   error
+}
+
+// initialValue is the last known value of the property delivered to subscriber
+// which joined existing observation
+type initialValue struct {
+	sub  *Subscription
+	prop EProperty
+}
+
+// Subscribe calls fn every time the property changes.
+//
+// Observer ID is allocated automatically and subscribers of the same property and format
+// share single mpv observer, which is removed after the last subscription is closed.
+// If property was already observed, fn is called with the last known value as soon as
+// events are read (the reader is woken up with Wakeup). Otherwise mpv sends the current value as the first change.
+//
+// fn is called from goroutine which reads events (EventWait or Events), so it should not block.
+func (m *Mpv) Subscribe(name string, format Format, fn func(EProperty)) (*Subscription, error) {
+	sub := &Subscription{m: m, fn: fn}
+	key := observationKey{name: name, format: format}
+
+	for {
+		m.state.mu.Lock()
+		obs, ok := m.state.observations[key]
+		if !ok {
+			break
+		}
+		m.state.mu.Unlock()
+
+		// observation is joined only after mpv accepted it
+		<-obs.ready
+		if obs.err != nil {
+			return nil, obs.err
+		}
+
+		m.state.mu.Lock()
+		if m.state.observations[key] != obs {
+			// the last subscription was closed meanwhile
+			m.state.mu.Unlock()
+			continue
+		}
+		sub.obs = obs
+		obs.subs = append(obs.subs, sub)
+		last := obs.last
+		if last != nil {
+			m.state.initial = append(m.state.initial, initialValue{sub: sub, prop: *last})
+		}
+		m.state.mu.Unlock()
+
+		if last != nil {
+			m.Wakeup()
+		}
+		return sub, nil
+	}
+
+	if m.state.observations == nil {
+		m.state.observations = map[observationKey]*observation{}
+		m.state.observationIDs = map[uint64]*observation{}
+	}
+	obs := &observation{id: m.state.allocID(), key: key, subs: []*Subscription{sub}, ready: make(chan struct{})}
+	m.state.observations[key] = obs
+	m.state.observationIDs[obs.id] = obs
+	sub.obs = obs
+	m.state.mu.Unlock()
+
+	err := m.ObserveProperty(name, obs.id, format)
+	if err != nil {
+		m.state.mu.Lock()
+		m.state.removeObservation(obs)
+		m.state.mu.Unlock()
+	}
+	obs.err = err
+	close(obs.ready)
+
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SubscribeProperty is like Subscribe, it implements Subscriber
+func (m *Mpv) SubscribeProperty(name string, format Format, fn func(EProperty)) (io.Closer, error) {
+	sub, err := m.Subscribe(name, format, fn)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Close removes the subscription. It is safe to call it multiple times.
+func (s *Subscription) Close() error {
+	var err error
+	s.close.Do(func() {
+		state := s.m.state
+
+		state.mu.Lock()
+		s.closed = true
+		for i, v := range s.obs.subs {
+			if v == s {
+				s.obs.subs = append(s.obs.subs[:i:i], s.obs.subs[i+1:]...)
+				break
+			}
+		}
+		last := len(s.obs.subs) == 0
+		if last {
+			state.removeObservation(s.obs)
+		}
+		state.mu.Unlock()
+
+		if last {
+			_, err = s.m.UnObserveProperty(s.obs.id)
+		}
+	})
+	return err
+}
+
+// removeObservation must be called with locked mutex
+func (s *state) removeObservation(obs *observation) {
+	if s.observations[obs.key] == obs {
+		delete(s.observations, obs.key)
+	}
+	delete(s.observationIDs, obs.id)
+}
+
+// deliverInitial calls subscribers which joined existing observation with its last value
+func (s *state) deliverInitial() {
+	s.mu.Lock()
+	initial := s.initial
+	s.initial = nil
+	s.mu.Unlock()
+
+	for _, v := range initial {
+		v := v
+		s.mu.Lock()
+		closed := v.sub.closed
+		s.mu.Unlock()
+
+		if !closed {
+			ev := &Event{ID: v.sub.obs.id, EventID: EventPropertyChange, Data: v.prop}
+			s.call(ev, func() { v.sub.fn(v.prop) })
+		}
+	}
+}
+
+// notifySubscribers calls subscribers of observation which sent the event
+func (s *state) notifySubscribers(ev *Event) {
+	prop := ev.Data.(EProperty)
+
+	s.mu.Lock()
+	obs, ok := s.observationIDs[ev.ID]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	obs.last = &prop
+	subs := obs.subs
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		s.call(ev, func() { sub.fn(prop) })
+	}
+}
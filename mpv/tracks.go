@@ -0,0 +1,141 @@
+package mpv
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// TrackType is type of track in "track-list"
+type TrackType string
+
+const (
+	TrackVideo    TrackType = "video"
+	TrackAudio    TrackType = "audio"
+	TrackSubtitle TrackType = "sub"
+)
+
+// Track is single entry of "track-list" property.
+// Demux fields are set only for tracks of matching type and only if demuxer knows them.
+type Track struct {
+	ID               int64     `mpv:"id"` // ID is unique only among tracks of the same type
+	Type             TrackType `mpv:"type"`
+	Title            string    `mpv:"title"`
+	Lang             string    `mpv:"lang"`
+	Codec            string    `mpv:"codec"`
+	Default          bool      `mpv:"default"`
+	Forced           bool      `mpv:"forced"`
+	External         bool      `mpv:"external"`
+	ExternalFilename string    `mpv:"external-filename"`
+	Selected         bool      `mpv:"selected"`
+
+	DemuxWidth        int64   `mpv:"demux-w"`
+	DemuxHeight       int64   `mpv:"demux-h"`
+	DemuxFPS          float64 `mpv:"demux-fps"`
+	DemuxChannelCount int64   `mpv:"demux-channel-count"`
+	DemuxChannels     string  `mpv:"demux-channels"`
+	DemuxSampleRate   int64   `mpv:"demux-samplerate"`
+}
+
+// SubAddFlag decides what happens with subtitle added with AddExternalSubtitle
+type SubAddFlag string
+
+const (
+	SubAddSelect SubAddFlag = "select" // select the subtitle immediately
+	SubAddAuto   SubAddFlag = "auto"   // do not select the subtitle
+	SubAddCached SubAddFlag = "cached" // select already added subtitle with the same file name instead of adding it again
+)
+
+// Tracks manages audio, video and subtitle tracks of current file
+type Tracks struct {
+	p Player
+}
+
+// NewTracks returns manager of tracks of p
+func NewTracks(p Player) *Tracks {
+	return &Tracks{p: p}
+}
+
+// List returns all tracks of current file
+func (t *Tracks) List() ([]Track, error) {
+	node, err := getNode(t.p, "track-list")
+	if err != nil {
+		return nil, err
+	}
+	return decodeTracks(node)
+}
+
+func decodeTracks(node *Node) ([]Track, error) {
+	var tracks []Track
+	if err := Unmarshal(node, &tracks); err != nil {
+		return nil, &OpError{Op: "get_property", Name: "track-list", Code: ErrPropertyFormat, Err: err}
+	}
+	return tracks, nil
+}
+
+// SelectVideo selects video track with provided ID
+func (t *Tracks) SelectVideo(id int64) error {
+	return t.p.SetProperty("vid", id, FormatInt64)
+}
+
+// SelectAudio selects audio track with provided ID
+func (t *Tracks) SelectAudio(id int64) error {
+	return t.p.SetProperty("aid", id, FormatInt64)
+}
+
+// SelectSubtitle selects subtitle track with provided ID
+func (t *Tracks) SelectSubtitle(id int64) error {
+	return t.p.SetProperty("sid", id, FormatInt64)
+}
+
+// DisableVideo disables video output
+func (t *Tracks) DisableVideo() error {
+	return t.p.SetPropertyString("vid", "no")
+}
+
+// DisableAudio disables audio output
+func (t *Tracks) DisableAudio() error {
+	return t.p.SetPropertyString("aid", "no")
+}
+
+// DisableSubtitles hides subtitles
+func (t *Tracks) DisableSubtitles() error {
+	return t.p.SetPropertyString("sid", "no")
+}
+
+// AddExternalSubtitle loads subtitle file and returns ID of the new track
+func (t *Tracks) AddExternalSubtitle(path string, flag SubAddFlag) (int64, error) {
+	result, err := t.p.CommandNode(&Node{Format: FormatNodeMap, Data: NodeMap{
+		"name":  {Data: "sub-add", Format: FormatString},
+		"url":   {Data: path, Format: FormatString},
+		"flags": {Data: string(flag), Format: FormatString},
+	}})
+	if err != nil {
+		return 0, err
+	}
+
+	values, _ := result.Data.(NodeMap)
+	id, ok := values["track_id"].Data.(int64)
+	if !ok {
+		return 0, &OpError{Op: "command_node", Name: "sub-add", Code: ErrGeneric, Err: errors.New("result does not contain track_id")}
+	}
+	return id, nil
+}
+
+// RemoveExternalSubtitle removes subtitle track with provided ID added by AddExternalSubtitle
+func (t *Tracks) RemoveExternalSubtitle(id int64) error {
+	return t.p.Command([]string{"sub-remove", strconv.FormatInt(id, 10)})
+}
+
+// OnChange calls fn with all tracks every time the track list changes.
+// Player must implement Subscriber.
+func (t *Tracks) OnChange(fn func([]Track)) (io.Closer, error) {
+	return subscribe(t.p, "track-list", FormatNode, func(e EProperty) {
+		node, _ := e.Property.(*Node)
+		tracks, err := decodeTracks(node)
+		if err != nil {
+			return
+		}
+		fn(tracks)
+	})
+}
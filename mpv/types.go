@@ -0,0 +1,38 @@
+package mpv
+
+type Event struct {
+	ID      uint64
+	Error   Error
+	Data    interface{}
+	EventID EventID
+}
+
+type EProperty struct {
+	Name     string
+	Format   Format
+	Property interface{}
+}
+
+type ELogMessage struct {
+	Prefix, Text string
+	Level        LogLevel
+}
+
+type EClientMessage []string
+
+type EStartFile int64
+
+type EEndFile struct {
+	PlaylistEntryID       int64
+	PlaylistInsertID      int64
+	PlaylistInsertEntries int
+	Error                 Error
+	Reason                EndFileReason
+}
+
+type EHook struct {
+	Name string
+	ID   uint64
+}
+
+type ECommandReply *Node
@@ -1,6 +1,10 @@
+//go:build cgo && !nolibmpv
+
 #include <mpv/client.h>
 #include <stdlib.h>
 #include <stdio.h>
+#include "utils.h"
+#include "_cgo_export.h"
 
 char** makeStringArray(int length) {
 	return calloc(sizeof(char*), length);
@@ -10,10 +14,83 @@ void setString(char** arr, int index, char* value) {
 	arr[index] = value;
 }
 
-mpv_node* makeNodeList(int length) {
-	return calloc(sizeof(mpv_node), length);
+int createSWRenderContext(mpv_render_context** res, mpv_handle* mpv) {
+	mpv_render_param params[] = {
+		{MPV_RENDER_PARAM_API_TYPE, MPV_RENDER_API_TYPE_SW},
+		{MPV_RENDER_PARAM_INVALID, NULL},
+	};
+	return mpv_render_context_create(res, mpv, params);
+}
+
+int renderSW(mpv_render_context* ctx, int width, int height, char* format, size_t stride, void* pixels) {
+	int size[2] = {width, height};
+	mpv_render_param params[] = {
+		{MPV_RENDER_PARAM_SW_SIZE, size},
+		{MPV_RENDER_PARAM_SW_FORMAT, format},
+		{MPV_RENDER_PARAM_SW_STRIDE, &stride},
+		{MPV_RENDER_PARAM_SW_POINTER, pixels},
+		{MPV_RENDER_PARAM_INVALID, NULL},
+	};
+	return mpv_render_context_render(ctx, params);
+}
+
+static void renderUpdateTrampoline(void* ctx) {
+	goRenderUpdateCallback((uintptr_t)ctx);
+}
+
+void setRenderUpdateCallback(mpv_render_context* ctx, uintptr_t handle) {
+	if (handle == 0) {
+		mpv_render_context_set_update_callback(ctx, NULL, NULL);
+		return;
+	}
+	mpv_render_context_set_update_callback(ctx, renderUpdateTrampoline, (void*)handle);
+}
+
+static int streamOpenTrampoline(void* userData, char* uri, mpv_stream_cb_info* info) {
+	return goStreamOpen((uintptr_t)userData, uri, info);
+}
+
+static int64_t streamReadTrampoline(void* cookie, char* buf, uint64_t nbytes) {
+	return goStreamRead((uintptr_t)cookie, buf, nbytes);
+}
+
+static int64_t streamSeekTrampoline(void* cookie, int64_t offset) {
+	return goStreamSeek((uintptr_t)cookie, offset);
 }
 
-void setNodeListElement(mpv_node* values, int index, mpv_node value) {
-	values[index] = value;
-}
\ No newline at end of file
+static int64_t streamSizeTrampoline(void* cookie) {
+	return goStreamSize((uintptr_t)cookie);
+}
+
+static void streamCloseTrampoline(void* cookie) {
+	goStreamClose((uintptr_t)cookie);
+}
+
+static void streamCancelTrampoline(void* cookie) {
+	goStreamCancel((uintptr_t)cookie);
+}
+
+int addStreamProtocol(mpv_handle* ctx, char* protocol, uintptr_t handle) {
+	return mpv_stream_cb_add_ro(ctx, protocol, (void*)handle, streamOpenTrampoline);
+}
+
+void setStreamCallbacks(mpv_stream_cb_info* info, uintptr_t cookie, int seekable, int cancelable) {
+	info->cookie = (void*)cookie;
+	info->read_fn = streamReadTrampoline;
+	info->close_fn = streamCloseTrampoline;
+	info->size_fn = streamSizeTrampoline;
+	info->seek_fn = seekable ? streamSeekTrampoline : NULL;
+	info->cancel_fn = cancelable ? streamCancelTrampoline : NULL;
+}
+
+static void wakeupTrampoline(void* ctx) {
+	goWakeupCallback((uintptr_t)ctx);
+}
+
+void setWakeupCallback(mpv_handle* ctx, uintptr_t handle) {
+	if (handle == 0) {
+		mpv_set_wakeup_callback(ctx, NULL, NULL);
+		return;
+	}
+	mpv_set_wakeup_callback(ctx, wakeupTrampoline, (void*)handle);
+}
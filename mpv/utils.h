@@ -1,7 +1,16 @@
 #include <mpv/client.h>
-
-mpv_node* makeNodeList(int);
-void setNodeListElement(mpv_node*, int, mpv_node);
+#include <mpv/render.h>
+#include <mpv/stream_cb.h>
+#include <stdint.h>
 
 char** makeStringArray(int);
-void setString(char**, int, char*);
\ No newline at end of file
+void setString(char**, int, char*);
+
+int createSWRenderContext(mpv_render_context**, mpv_handle*);
+int renderSW(mpv_render_context*, int, int, char*, size_t, void*);
+void setRenderUpdateCallback(mpv_render_context*, uintptr_t);
+
+int addStreamProtocol(mpv_handle*, char*, uintptr_t);
+void setStreamCallbacks(mpv_stream_cb_info*, uintptr_t, int, int);
+
+void setWakeupCallback(mpv_handle*, uintptr_t);
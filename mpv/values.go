@@ -0,0 +1,58 @@
+//go:build cgo && !nolibmpv
+
+package mpv
+
+// SetOptionValue is like SetOption, but format is inferred from type of the value.
+// See NewNode for supported types.
+func (m *Mpv) SetOptionValue(name string, value interface{}) error {
+	data, format, err := inferFormat(value)
+	if err != nil {
+		return &OpError{Op: "set_option", Name: name, Code: ErrOptionFormat, Err: err}
+	}
+	return m.SetOption(name, data, format)
+}
+
+// SetPropertyValue is like SetProperty, but format is inferred from type of the value.
+// See NewNode for supported types.
+func (m *Mpv) SetPropertyValue(name string, value interface{}) error {
+	data, format, err := inferFormat(value)
+	if err != nil {
+		return &OpError{Op: "set_property", Name: name, Code: ErrPropertyFormat, Err: err}
+	}
+	return m.SetProperty(name, data, format)
+}
+
+// SetPropertyValueAsync is like SetPropertyAsync, but format is inferred from type of the value.
+// See NewNode for supported types.
+func (m *Mpv) SetPropertyValueAsync(name string, value interface{}, id uint64) error {
+	data, format, err := inferFormat(value)
+	if err != nil {
+		return &OpError{Op: "set_property_async", Name: name, Code: ErrPropertyFormat, Err: err}
+	}
+	return m.SetPropertyAsync(name, data, id, format)
+}
+
+// GetBool returns value of property with FormatFlag
+func (m *Mpv) GetBool(name string) (bool, error) {
+	return getBool(m, name)
+}
+
+// GetInt64 returns value of property with FormatInt64
+func (m *Mpv) GetInt64(name string) (int64, error) {
+	return getInt64(m, name)
+}
+
+// GetFloat64 returns value of property with FormatDouble
+func (m *Mpv) GetFloat64(name string) (float64, error) {
+	return getFloat64(m, name)
+}
+
+// GetString returns value of property with FormatString
+func (m *Mpv) GetString(name string) (string, error) {
+	return getString(m, name)
+}
+
+// GetNode returns value of property with FormatNode
+func (m *Mpv) GetNode(name string) (*Node, error) {
+	return getNode(m, name)
+}
@@ -0,0 +1,216 @@
+package mpvfake
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+// ErrEventsRunning is returned when events of the fake are already consumed by other reader
+var ErrEventsRunning = errors.New("events of this handle are already consumed by other reader")
+
+type scheduledEvent struct {
+	at time.Duration
+	ev *mpv.Event
+}
+
+// Now returns current time of fake clock. The clock starts at zero and moves only with Advance.
+func (m *Mpv) Now() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.now
+}
+
+// Advance moves fake clock forward and queues all events scheduled up to the new time, in order.
+func (m *Mpv) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now += d
+	i := 0
+	for ; i < len(m.scheduled) && m.scheduled[i].at <= m.now; i++ {
+		m.pushLocked(m.scheduled[i].ev)
+	}
+	m.scheduled = m.scheduled[i:]
+}
+
+// Push queues event right away
+func (m *Mpv) Push(ev *mpv.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pushLocked(ev)
+}
+
+// PushAfter schedules event to be queued when fake clock advances by d.
+// Events scheduled for the same time are queued in order of scheduling.
+func (m *Mpv) PushAfter(d time.Duration, ev *mpv.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if d <= 0 {
+		m.pushLocked(ev)
+		return
+	}
+
+	at := m.now + d
+	i := sort.Search(len(m.scheduled), func(i int) bool { return m.scheduled[i].at > at })
+	m.scheduled = append(m.scheduled, scheduledEvent{})
+	copy(m.scheduled[i+1:], m.scheduled[i:])
+	m.scheduled[i] = scheduledEvent{at: at, ev: ev}
+}
+
+func (m *Mpv) pushLocked(ev *mpv.Event) {
+	if m.disabled[ev.EventID] && ev.EventID != mpv.EventShutdown {
+		return
+	}
+	m.queue = append(m.queue, ev)
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// StartFile queues EventStartFile
+func (m *Mpv) StartFile(playlistEntryID int64) {
+	m.Push(&mpv.Event{EventID: mpv.EventStartFile, Data: mpv.EStartFile(playlistEntryID)})
+}
+
+// FileLoaded queues EventFileLoaded
+func (m *Mpv) FileLoaded() {
+	m.Push(&mpv.Event{EventID: mpv.EventFileLoaded})
+}
+
+// EndFile queues EventEndFile
+func (m *Mpv) EndFile(data mpv.EEndFile) {
+	m.Push(&mpv.Event{EventID: mpv.EventEndFile, Data: data})
+}
+
+// ClientMessage queues EventClientMessage, like script-message command sent to this client
+func (m *Mpv) ClientMessage(args ...string) {
+	m.Push(&mpv.Event{EventID: mpv.EventClientMessage, Data: mpv.EClientMessage(args)})
+}
+
+// LogMessage queues EventLogMessage, if level was enabled with RequestLogMessages
+func (m *Mpv) LogMessage(level mpv.LogLevel, prefix, text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if level > m.logLevel {
+		return
+	}
+	m.pushLocked(&mpv.Event{EventID: mpv.EventLogMessage, Data: mpv.ELogMessage{Prefix: prefix, Text: text, Level: level}})
+}
+
+// RunHook queues EventHook for every hook registered with HookAdd under provided name,
+// ordered by priority. Hooks stay pending until they are continued with HookContinue.
+func (m *Mpv) RunHook(name string) []mpv.EHook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var hooks []hook
+	for _, h := range m.hooks {
+		if h.name == name {
+			hooks = append(hooks, h)
+		}
+	}
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].priority < hooks[j].priority })
+
+	var result []mpv.EHook
+	for _, h := range hooks {
+		m.lastHookID++
+		data := mpv.EHook{Name: name, ID: m.lastHookID}
+		m.pendingHooks[data.ID] = data
+		m.pushLocked(&mpv.Event{ID: h.id, EventID: mpv.EventHook, Data: data})
+		result = append(result, data)
+	}
+	return result
+}
+
+// EventWait returns the next queued event.
+//
+// timeout is in seconds of real time (not fake clock), negative value waits forever
+// and zero does not wait at all. EventNone is returned on timeout and EventShutdown after Close.
+func (m *Mpv) EventWait(timeout float64) *mpv.Event {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	return m.next(nil, expired)
+}
+
+func (m *Mpv) next(done <-chan struct{}, expired <-chan time.Time) *mpv.Event {
+	for {
+		m.mu.Lock()
+		if len(m.queue) > 0 {
+			ev := m.queue[0]
+			m.queue[0] = nil
+			m.queue = m.queue[1:]
+			m.mu.Unlock()
+
+			m.subscribers.Notify(ev)
+			return ev
+		}
+		closed := m.closed
+		m.mu.Unlock()
+
+		if closed {
+			return &mpv.Event{EventID: mpv.EventShutdown}
+		}
+
+		select {
+		case <-m.wake:
+		case <-expired:
+			return &mpv.Event{EventID: mpv.EventNone}
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// Events starts goroutine which sends queued events to returned channel.
+//
+// Channel is closed after EventShutdown or when ctx is cancelled.
+// Only one reader is allowed and EventWait must not be called while it is running.
+func (m *Mpv) Events(ctx context.Context) (<-chan *mpv.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reading {
+		return nil, ErrEventsRunning
+	}
+	m.reading = true
+
+	out := make(chan *mpv.Event)
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			m.reading = false
+			m.mu.Unlock()
+			close(out)
+		}()
+
+		for {
+			ev := m.next(ctx.Done(), nil)
+			if ev == nil {
+				return
+			}
+
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return
+			}
+			if ev.EventID == mpv.EventShutdown {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,601 @@
+// Package mpvfake provides in-memory fake of mpv handle for unit tests.
+//
+// Mpv mimics method set of mpv.Mpv (and implements mpv.Player) without libmpv or media files.
+// Properties are kept in memory with their formats, issued commands are recorded
+// and events can be scripted on controllable clock.
+package mpvfake
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+var (
+	_ mpv.Player     = (*Mpv)(nil)
+	_ mpv.Subscriber = (*Mpv)(nil)
+)
+
+// CommandHandler implements command of the fake.
+// args are always NodeList (positional arguments) or NodeMap (named arguments).
+type CommandHandler func(args *mpv.Node) (*mpv.Node, error)
+
+// Command is command recorded by the fake
+type Command struct {
+	Args []string  // Args of command issued with string arguments (Command, CommandReturn, ...)
+	Node *mpv.Node // Node of command issued with CommandNode or CommandAsyncNode
+	At   time.Duration
+}
+
+// Name returns name of the command
+func (c Command) Name() string {
+	if c.Node == nil {
+		return mpv.CommandName(c.Args)
+	}
+	return mpv.CommandNodeName(c.Node)
+}
+
+type property struct {
+	data   interface{}
+	format mpv.Format
+}
+
+type observer struct {
+	id     uint64
+	name   string
+	format mpv.Format
+}
+
+type hook struct {
+	name     string
+	priority int
+	id       uint64
+}
+
+// Mpv is fake mpv handle. Zero value is not usable, use New.
+type Mpv struct {
+	mu   sync.Mutex
+	name string
+
+	properties map[string]property
+	failures   map[string]mpv.Error
+	observers  []observer
+	commands   []Command
+	handlers   map[string]CommandHandler
+
+	hooks        []hook
+	pendingHooks map[uint64]mpv.EHook
+	lastHookID   uint64
+
+	subscribers *mpv.PropertySubscribers
+
+	disabled  map[mpv.EventID]bool
+	logLevel  mpv.LogLevel
+	now       time.Duration
+	scheduled []scheduledEvent
+	queue     []*mpv.Event
+	wake      chan struct{}
+	closed    bool
+	reading   bool
+}
+
+// New creates fake handle with provided client name
+func New(name string) *Mpv {
+	return &Mpv{
+		name:         name,
+		properties:   map[string]property{},
+		failures:     map[string]mpv.Error{},
+		handlers:     map[string]CommandHandler{},
+		pendingHooks: map[uint64]mpv.EHook{},
+		subscribers:  mpv.NewPropertySubscribers(firstSubscriptionID),
+		disabled:     map[mpv.EventID]bool{},
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Initialize does nothing, it exists to mimic mpv.Mpv
+func (m *Mpv) Initialize() error {
+	return nil
+}
+
+// Close queues EventShutdown. Events are still delivered until EventShutdown is read.
+func (m *Mpv) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.closed {
+		m.closed = true
+		m.pushLocked(&mpv.Event{EventID: mpv.EventShutdown})
+	}
+	return nil
+}
+
+// Destroy is the same as Close
+func (m *Mpv) Destroy() {
+	_ = m.Close()
+}
+
+// Terminate is the same as Close
+func (m *Mpv) Terminate() {
+	_ = m.Close()
+}
+
+// ClientName returns name passed to New
+func (m *Mpv) ClientName() string {
+	return m.name
+}
+
+// ClientID always returns 1
+func (m *Mpv) ClientID() int64 {
+	return 1
+}
+
+// InternalTime returns time of fake clock in microseconds
+func (m *Mpv) InternalTime() int64 {
+	return int64(m.Now() / time.Microsecond)
+}
+
+// FailProperty makes all following reads and writes of the property fail with code.
+// ErrSuccess removes the failure.
+func (m *Mpv) FailProperty(name string, code mpv.Error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if code == mpv.ErrSuccess {
+		delete(m.failures, name)
+		return
+	}
+	m.failures[name] = code
+}
+
+// SetOption sets option, options are stored as properties with the same name
+func (m *Mpv) SetOption(name string, option interface{}, format mpv.Format) error {
+	return m.setProperty("set_option", name, option, format)
+}
+
+// SetOptionString sets option from string
+func (m *Mpv) SetOptionString(name, option string) error {
+	return m.setPropertyString("set_option_string", name, option)
+}
+
+// SetProperty stores the value of the property with provided format and notifies observers.
+// Unlike mpv, setting property which does not exist creates it.
+func (m *Mpv) SetProperty(name string, value interface{}, format mpv.Format) error {
+	return m.setProperty("set_property", name, value, format)
+}
+
+// SetPropertyString sets property from string.
+// If property already exists, the string is parsed into its format like mpv does.
+func (m *Mpv) SetPropertyString(name, value string) error {
+	return m.setPropertyString("set_property_string", name, value)
+}
+
+// SetPropertyAsync sets property and queues EventSetPropertyReply with provided id
+func (m *Mpv) SetPropertyAsync(name string, value interface{}, id uint64, format mpv.Format) error {
+	err := m.SetProperty(name, value, format)
+	m.Push(&mpv.Event{ID: id, Error: errorCode(err), EventID: mpv.EventSetPropertyReply})
+	return nil
+}
+
+func (m *Mpv) setProperty(op, name string, value interface{}, format mpv.Format) error {
+	prop, err := newProperty(value, format)
+	if err != nil {
+		return &mpv.OpError{Op: op, Name: name, Code: mpv.ErrPropertyFormat, Err: err}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if code, ok := m.failures[name]; ok {
+		return &mpv.OpError{Op: op, Name: name, Code: code}
+	}
+	m.properties[name] = prop
+	m.notifyLocked(name)
+	return nil
+}
+
+func (m *Mpv) setPropertyString(op, name, value string) error {
+	m.mu.Lock()
+	prop, ok := m.properties[name]
+	m.mu.Unlock()
+
+	if !ok || prop.format == mpv.FormatNone {
+		return m.setProperty(op, name, value, mpv.FormatString)
+	}
+
+	data, err := parseString(value, prop.format)
+	if err != nil {
+		return &mpv.OpError{Op: op, Name: name, Code: mpv.ErrPropertyFormat, Err: err}
+	}
+	return m.setProperty(op, name, data, prop.format)
+}
+
+// GetProperty returns value of the property converted to provided format.
+// Properties which were never set return ErrPropertyNotFound.
+func (m *Mpv) GetProperty(name string, format mpv.Format) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.getPropertyLocked("get_property", name, format)
+}
+
+func (m *Mpv) getPropertyLocked(op, name string, format mpv.Format) (interface{}, error) {
+	if code, ok := m.failures[name]; ok {
+		return nil, &mpv.OpError{Op: op, Name: name, Code: code}
+	}
+
+	prop, ok := m.properties[name]
+	if !ok {
+		return nil, &mpv.OpError{Op: op, Name: name, Code: mpv.ErrPropertyNotFound}
+	}
+
+	value, err := prop.convert(format)
+	if err != nil {
+		return nil, &mpv.OpError{Op: op, Name: name, Code: mpv.ErrPropertyFormat, Err: err}
+	}
+	return value, nil
+}
+
+// GetPropertyString returns value of the property formatted as string
+func (m *Mpv) GetPropertyString(name string) (string, error) {
+	value, err := m.GetProperty(name, mpv.FormatString)
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// GetPropertyOsdString is the same as GetPropertyString
+func (m *Mpv) GetPropertyOsdString(name string) (string, error) {
+	value, err := m.GetProperty(name, mpv.FormatOsdString)
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// GetPropertyAsync queues EventGetPropertyReply with value of the property and provided id
+func (m *Mpv) GetPropertyAsync(name string, id uint64, format mpv.Format) error {
+	value, err := m.GetProperty(name, format)
+	ev := &mpv.Event{ID: id, Error: errorCode(err), EventID: mpv.EventGetPropertyReply}
+	if err == nil {
+		ev.Data = mpv.EProperty{Name: name, Format: format, Property: value}
+	}
+	m.Push(ev)
+	return nil
+}
+
+// ObserveProperty registers observer of the property. Like mpv, the current value
+// is delivered as EventPropertyChange right away and then after every change.
+func (m *Mpv) ObserveProperty(name string, id uint64, format mpv.Format) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o := observer{id: id, name: name, format: format}
+	m.observers = append(m.observers, o)
+	m.pushLocked(m.propertyChangeLocked(o))
+	return nil
+}
+
+// UnObserveProperty removes all observers with provided id and returns their count
+func (m *Mpv) UnObserveProperty(id uint64) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var kept []observer
+	for _, o := range m.observers {
+		if o.id != id {
+			kept = append(kept, o)
+		}
+	}
+	removed := len(m.observers) - len(kept)
+	m.observers = kept
+	return removed, nil
+}
+
+// firstSubscriptionID is the first observer ID used by SubscribeProperty, the same as in mpv.Mpv
+const firstSubscriptionID = 1 << 63
+
+// SubscribeProperty calls fn every time the property changes, starting with its current value.
+// fn is called when the change event is read (EventWait or Events).
+func (m *Mpv) SubscribeProperty(name string, format mpv.Format, fn func(mpv.EProperty)) (io.Closer, error) {
+	return m.subscribers.Subscribe(m, name, format, fn)
+}
+
+// SetPanicHandler sets function called when subscriber panics, like mpv.Mpv.SetPanicHandler.
+// Panic is recovered and events are still delivered, even if there is no panic handler. nil fn removes the handler.
+func (m *Mpv) SetPanicHandler(fn func(ev *mpv.Event, recovered interface{})) {
+	m.subscribers.SetPanicHandler(fn)
+}
+
+func (m *Mpv) notifyLocked(name string) {
+	for _, o := range m.observers {
+		if o.name == name {
+			m.pushLocked(m.propertyChangeLocked(o))
+		}
+	}
+}
+
+func (m *Mpv) propertyChangeLocked(o observer) *mpv.Event {
+	data := mpv.EProperty{Name: o.name, Format: mpv.FormatNone}
+	if o.format != mpv.FormatNone {
+		if value, err := m.getPropertyLocked("observe_property", o.name, o.format); err == nil {
+			data.Format, data.Property = o.format, value
+		}
+	}
+	return &mpv.Event{ID: o.id, EventID: mpv.EventPropertyChange, Data: data}
+}
+
+// HandleCommand sets handler of the command with provided name.
+// Commands without handler succeed and return FormatNone node.
+func (m *Mpv) HandleCommand(name string, fn CommandHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers[name] = fn
+}
+
+// Commands returns all commands issued so far
+func (m *Mpv) Commands() []Command {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Command(nil), m.commands...)
+}
+
+// ClearCommands removes recorded commands
+func (m *Mpv) ClearCommands() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commands = nil
+}
+
+// Command records and runs the command
+func (m *Mpv) Command(args []string) error {
+	_, err := m.CommandReturn(args)
+	return err
+}
+
+// CommandString splits command on whitespace and runs it like Command
+func (m *Mpv) CommandString(command string) error {
+	return m.Command(strings.Fields(command))
+}
+
+// CommandReturn records and runs the command and returns its result
+func (m *Mpv) CommandReturn(args []string) (*mpv.Node, error) {
+	list := make(mpv.NodeList, len(args))
+	for i, arg := range args {
+		list[i] = mpv.Node{Data: arg, Format: mpv.FormatString}
+	}
+
+	m.mu.Lock()
+	m.commands = append(m.commands, Command{Args: append([]string(nil), args...), At: m.now})
+	m.mu.Unlock()
+
+	return m.runCommand(mpv.CommandName(args), &mpv.Node{Data: list, Format: mpv.FormatNodeArray})
+}
+
+// CommandNode records and runs the command passed as Node
+func (m *Mpv) CommandNode(args *mpv.Node) (*mpv.Node, error) {
+	m.mu.Lock()
+	m.commands = append(m.commands, Command{Node: args, At: m.now})
+	m.mu.Unlock()
+
+	return m.runCommand(mpv.CommandNodeName(args), args)
+}
+
+// CommandAsync runs the command and queues EventCommandReply with provided id
+func (m *Mpv) CommandAsync(args []string, id uint64) error {
+	result, err := m.CommandReturn(args)
+	m.pushReply(id, result, err)
+	return nil
+}
+
+// CommandAsyncNode runs the command and queues EventCommandReply with provided id
+func (m *Mpv) CommandAsyncNode(args *mpv.Node, id uint64) error {
+	result, err := m.CommandNode(args)
+	m.pushReply(id, result, err)
+	return nil
+}
+
+// AbortAsyncCommand does nothing, async commands of the fake complete immediately
+func (m *Mpv) AbortAsyncCommand(id uint64) {}
+
+func (m *Mpv) pushReply(id uint64, result *mpv.Node, err error) {
+	ev := &mpv.Event{ID: id, Error: errorCode(err), EventID: mpv.EventCommandReply}
+	if err == nil {
+		ev.Data = mpv.ECommandReply(result)
+	}
+	m.Push(ev)
+}
+
+func (m *Mpv) runCommand(name string, args *mpv.Node) (*mpv.Node, error) {
+	m.mu.Lock()
+	fn, ok := m.handlers[name]
+	m.mu.Unlock()
+
+	if !ok {
+		return &mpv.Node{Format: mpv.FormatNone}, nil
+	}
+
+	result, err := fn(args)
+	if err != nil {
+		if _, ok := err.(*mpv.OpError); !ok {
+			err = &mpv.OpError{Op: "command", Name: name, Code: errorCode(err), Err: err}
+		}
+		return nil, err
+	}
+	if result == nil {
+		result = &mpv.Node{Format: mpv.FormatNone}
+	}
+	return result, nil
+}
+
+// RequestEvent enables or disables delivery of the event
+func (m *Mpv) RequestEvent(event mpv.EventID, status bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.disabled[event] = !status
+	return nil
+}
+
+// RequestLogMessages sets log level of messages queued with LogMessage
+func (m *Mpv) RequestLogMessages(level mpv.LogLevel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.logLevel = level
+	return nil
+}
+
+// HookAdd registers hook, which is queued as EventHook by RunHook
+func (m *Mpv) HookAdd(name string, priority int, id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{name: name, priority: priority, id: id})
+	return nil
+}
+
+// HookContinue marks the hook as continued
+func (m *Mpv) HookContinue(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pendingHooks[id]; !ok {
+		return &mpv.OpError{Op: "hook_continue", Code: mpv.ErrInvalidParameter}
+	}
+	delete(m.pendingHooks, id)
+	return nil
+}
+
+// PendingHooks returns hooks which were queued by RunHook, but not continued yet
+func (m *Mpv) PendingHooks() []mpv.EHook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]mpv.EHook, 0, len(m.pendingHooks))
+	for _, h := range m.pendingHooks {
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// newProperty validates value against format and converts it into stored representation
+func newProperty(value interface{}, format mpv.Format) (property, error) {
+	node, err := mpv.NewNode(value)
+	if err != nil {
+		return property{}, err
+	}
+
+	switch format {
+	case mpv.FormatNode:
+		if node.Format == mpv.FormatNode {
+			return newProperty(node.Data, mpv.FormatNode)
+		}
+		return property{data: node.Data, format: node.Format}, nil
+	case mpv.FormatDouble:
+		if v, ok := node.Data.(int64); ok {
+			return property{data: float64(v), format: format}, nil
+		}
+	case mpv.FormatOsdString:
+		format = mpv.FormatString
+	}
+
+	if node.Format != format {
+		return property{}, fmt.Errorf("value of type %T cannot be used as format %d", value, format)
+	}
+	return property{data: node.Data, format: format}, nil
+}
+
+// convert returns value of the property in provided format, the same way mpv does
+func (p property) convert(format mpv.Format) (interface{}, error) {
+	switch format {
+	case mpv.FormatNone:
+		return nil, nil
+	case mpv.FormatNode:
+		return &mpv.Node{Data: p.data, Format: p.format}, nil
+	case mpv.FormatString, mpv.FormatOsdString:
+		return formatString(p)
+	case mpv.FormatDouble:
+		if v, ok := p.data.(int64); ok {
+			return float64(v), nil
+		}
+	}
+
+	if p.format != format {
+		return nil, fmt.Errorf("property with format %d cannot be read as format %d", p.format, format)
+	}
+	return p.data, nil
+}
+
+func formatString(p property) (string, error) {
+	switch v := p.data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "yes", nil
+		}
+		return "no", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', 6, 64), nil
+	}
+
+	data, err := mpv.Node{Data: p.data, Format: p.format}.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func parseString(value string, format mpv.Format) (interface{}, error) {
+	switch format {
+	case mpv.FormatFlag:
+		switch value {
+		case "yes":
+			return true, nil
+		case "no":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a flag", value)
+	case mpv.FormatInt64:
+		return strconv.ParseInt(value, 10, 64)
+	case mpv.FormatDouble:
+		return strconv.ParseFloat(value, 64)
+	case mpv.FormatString, mpv.FormatOsdString, mpv.FormatNone:
+		return value, nil
+	}
+
+	var node mpv.Node
+	if err := node.UnmarshalJSON([]byte(value)); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func errorCode(err error) mpv.Error {
+	if err == nil {
+		return mpv.ErrSuccess
+	}
+	switch e := err.(type) {
+	case *mpv.OpError:
+		return e.Code
+	case mpv.Error:
+		return e
+	}
+	return mpv.ErrCommand
+}
@@ -0,0 +1,251 @@
+package mpvfake
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/HuntClauss/mpvgo/mpv"
+)
+
+func TestNewProperty(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		format mpv.Format
+		want   property
+	}{
+		{"int as double", 5, mpv.FormatDouble, property{data: 5.0, format: mpv.FormatDouble}},
+		{"osd string", "a", mpv.FormatOsdString, property{data: "a", format: mpv.FormatString}},
+		{"node keeps value format", int64(3), mpv.FormatNode, property{data: int64(3), format: mpv.FormatInt64}},
+		{"node", mpv.Node{Data: true, Format: mpv.FormatFlag}, mpv.FormatNode, property{data: true, format: mpv.FormatFlag}},
+		{"list", mpv.NodeList{}, mpv.FormatNode, property{data: mpv.NodeList{}, format: mpv.FormatNodeArray}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prop, err := newProperty(tt.value, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(prop, tt.want) {
+				t.Errorf("newProperty() = %#v, want %#v", prop, tt.want)
+			}
+		})
+	}
+
+	if _, err := newProperty(true, mpv.FormatInt64); err == nil {
+		t.Error("expected error for flag as int64")
+	}
+	if _, err := newProperty(1.5, mpv.FormatInt64); err == nil {
+		t.Error("expected error for double as int64")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	prop := property{data: int64(5), format: mpv.FormatInt64}
+	tests := []struct {
+		format mpv.Format
+		want   interface{}
+	}{
+		{mpv.FormatNone, nil},
+		{mpv.FormatInt64, int64(5)},
+		{mpv.FormatDouble, 5.0},
+		{mpv.FormatString, "5"},
+		{mpv.FormatOsdString, "5"},
+		{mpv.FormatNode, &mpv.Node{Data: int64(5), Format: mpv.FormatInt64}},
+	}
+
+	for _, tt := range tests {
+		value, err := prop.convert(tt.format)
+		if err != nil {
+			t.Errorf("convert(%d): %v", tt.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, tt.want) {
+			t.Errorf("convert(%d) = %#v, want %#v", tt.format, value, tt.want)
+		}
+	}
+
+	if _, err := prop.convert(mpv.FormatFlag); err == nil {
+		t.Error("expected error for int64 read as flag")
+	}
+	if _, err := (property{data: 0.5, format: mpv.FormatDouble}).convert(mpv.FormatInt64); err == nil {
+		t.Error("expected error for double read as int64")
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	tests := []struct {
+		prop property
+		want string
+	}{
+		{property{format: mpv.FormatNone}, ""},
+		{property{data: true, format: mpv.FormatFlag}, "yes"},
+		{property{data: false, format: mpv.FormatFlag}, "no"},
+		{property{data: int64(-3), format: mpv.FormatInt64}, "-3"},
+		{property{data: 0.5, format: mpv.FormatDouble}, "0.500000"},
+		{property{data: mpv.NodeList{{Data: "a", Format: mpv.FormatString}}, format: mpv.FormatNodeArray}, `["a"]`},
+	}
+
+	for _, tt := range tests {
+		got, err := formatString(tt.prop)
+		if err != nil {
+			t.Errorf("formatString(%#v): %v", tt.prop, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("formatString(%#v) = %q, want %q", tt.prop, got, tt.want)
+		}
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		value  string
+		format mpv.Format
+		want   interface{}
+	}{
+		{"yes", mpv.FormatFlag, true},
+		{"no", mpv.FormatFlag, false},
+		{"12", mpv.FormatInt64, int64(12)},
+		{"0.25", mpv.FormatDouble, 0.25},
+		{"a b", mpv.FormatString, "a b"},
+		{`{"a":1}`, mpv.FormatNodeMap, &mpv.Node{Format: mpv.FormatNodeMap, Data: mpv.NodeMap{"a": {Data: int64(1), Format: mpv.FormatInt64}}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseString(tt.value, tt.format)
+		if err != nil {
+			t.Errorf("parseString(%q, %d): %v", tt.value, tt.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseString(%q, %d) = %#v, want %#v", tt.value, tt.format, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		value  string
+		format mpv.Format
+	}{{"true", mpv.FormatFlag}, {"1.5", mpv.FormatInt64}, {"{", mpv.FormatNode}} {
+		if _, err := parseString(tt.value, tt.format); err == nil {
+			t.Errorf("parseString(%q, %d): expected error", tt.value, tt.format)
+		}
+	}
+}
+
+// queuedIDs reads all queued events and returns their IDs
+func queuedIDs(m *Mpv) []uint64 {
+	var ids []uint64
+	for {
+		ev := m.EventWait(0)
+		if ev.EventID == mpv.EventNone {
+			return ids
+		}
+		ids = append(ids, ev.ID)
+	}
+}
+
+func TestPushAfter(t *testing.T) {
+	m := New("test")
+
+	m.PushAfter(2*time.Second, &mpv.Event{ID: 1, EventID: mpv.EventSeek})
+	m.PushAfter(time.Second, &mpv.Event{ID: 2, EventID: mpv.EventSeek})
+	m.PushAfter(2*time.Second, &mpv.Event{ID: 3, EventID: mpv.EventSeek})
+	m.PushAfter(0, &mpv.Event{ID: 4, EventID: mpv.EventSeek})
+
+	if got, want := queuedIDs(m), []uint64{4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("before Advance: got %v, want %v", got, want)
+	}
+
+	m.Advance(1500 * time.Millisecond)
+	if got, want := queuedIDs(m), []uint64{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after 1.5s: got %v, want %v", got, want)
+	}
+
+	m.Advance(500 * time.Millisecond)
+	if got, want := queuedIDs(m), []uint64{1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after 2s: got %v, want %v", got, want)
+	}
+	if now := m.Now(); now != 2*time.Second {
+		t.Errorf("Now() = %v, want 2s", now)
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	m := New("test")
+	for _, h := range []struct {
+		name     string
+		priority int
+		id       uint64
+	}{{"on_load", 10, 1}, {"on_load", -5, 2}, {"on_unload", 0, 3}} {
+		if err := m.HookAdd(h.name, h.priority, h.id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hooks := m.RunHook("on_load")
+	if got, want := queuedIDs(m), []uint64{2, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("hook events = %v, want %v (ordered by priority)", got, want)
+	}
+	if pending := m.PendingHooks(); !reflect.DeepEqual(pending, hooks) {
+		t.Errorf("PendingHooks() = %v, want %v", pending, hooks)
+	}
+
+	if err := m.HookContinue(hooks[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.HookContinue(hooks[0].ID); err == nil {
+		t.Error("expected error for hook continued twice")
+	}
+	if pending := m.PendingHooks(); !reflect.DeepEqual(pending, hooks[1:]) {
+		t.Errorf("PendingHooks() = %v, want %v", pending, hooks[1:])
+	}
+}
+
+func TestSubscribeProperty(t *testing.T) {
+	m := New("test")
+	if err := m.SetProperty("volume", 50.0, mpv.FormatDouble); err != nil {
+		t.Fatal(err)
+	}
+
+	var recovered interface{}
+	m.SetPanicHandler(func(ev *mpv.Event, r interface{}) { recovered = r })
+
+	var values []interface{}
+	sub, err := m.SubscribeProperty("volume", mpv.FormatDouble, func(e mpv.EProperty) {
+		values = append(values, e.Property)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.SubscribeProperty("volume", mpv.FormatDouble, func(mpv.EProperty) { panic("subscriber failed") }); err != nil {
+		t.Fatal(err)
+	}
+
+	if ev := m.EventWait(0); ev.EventID != mpv.EventPropertyChange {
+		t.Fatalf("got event %d, want EventPropertyChange", ev.EventID)
+	}
+	if ev := m.EventWait(0); ev.EventID != mpv.EventPropertyChange {
+		t.Fatalf("got event %d, want EventPropertyChange", ev.EventID)
+	}
+	if recovered != "subscriber failed" {
+		t.Errorf("panic handler got %v, want subscriber failed", recovered)
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if err := m.SetProperty("volume", 60.0, mpv.FormatDouble); err != nil {
+		t.Fatal(err)
+	}
+	queuedIDs(m)
+
+	if want := []interface{}{50.0}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+}